@@ -17,38 +17,180 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/fluxcd/flux2/internal/utils"
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	apimachineryversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	imageautov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagereflectv1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 )
 
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check requirements and installation",
 	Long: `The check command will perform a series of checks to validate that
-the local environment is configured correctly and if the installed components are healthy.`,
+the local environment is configured correctly and if the installed components are healthy.
+
+With --output yaml, the results are printed as a document with two lists:
+'components', where each controller has the fields 'name', 'ready',
+'replicas.desired', 'replicas.ready', 'image', 'version' and 'conditions'
+(the type, status, reason and message of each deployment condition),
+and 'checks', with the name, category, status, detail and version of each check.`,
 	Example: `  # Run pre-installation checks
   flux check --pre
 
   # Run installation checks
   flux check
+
+  # Run installation checks and validate the controllers deployment strategy
+  flux check --check-deployment-strategy
+
+  # Run installation checks and validate that the controllers metrics can be scraped
+  flux check --check-metrics
+
+  # Run installation checks and validate the controllers resource requests and limits
+  flux check --check-resources
+
+  # Run installation checks and print the flags each controller was started with
+  flux check --show-controller-flags
+
+  # Run installation checks and verify the controllers replica count
+  flux check --expected-replicas source-controller=2,kustomize-controller=2
+
+  # Run installation checks and report when Flux was installed and last updated
+  flux check --since-install
+
+  # Run installation checks and validate the controllers liveness and readiness probes
+  flux check --check-probes
+
+  # Run installation checks and validate the security context of the controllers
+  flux check --check-security-context
+
+  # Run installation checks and audit the image references and pull policies of the controllers
+  flux check --check-image-policy
+
+  # Run installation checks and print the proxy settings of each controller
+  flux check --show-controller-env
+
+  # Run installation checks and verify the labels required by the namespace policies
+  flux check --require-namespace-labels pod-security.kubernetes.io/enforce=restricted
+
+  # Run installation checks and report the log level of each controller
+  flux check --show-log-level
+
+  # Run installation checks and verify that the replicas of HA controllers are spread across nodes
+  flux check --check-ha
+
+  # Run installation checks and warn about controllers that restarted more than 3 times
+  flux check --controller-restart-count 3
+
+  # Run installation checks and stop at the first failure
+  flux check --fail-fast
+
+  # Run installation checks with an older kubectl, reporting the version mismatch as a warning
+  flux check --tolerate-version-mismatch
+
+  # Run installation checks and fail on any warning
+  flux check --strict
+
+  # Run installation checks and print only the tally of each check category
+  flux check --summary-only
+
+  # Print the health of each controller and the check results as YAML
+  flux check --output yaml
+
+  # Export the check results in CSV format
+  flux check --output csv > check.csv
+
+  # Report the failed and warned checks as GitHub Actions annotations
+  flux check --output github-annotations
+
+  # Print the check results to the terminal and write them to a JUnit XML file
+  flux check --output-file check.xml --output-file-format junit
+
+  # Push the check results as metrics to a Prometheus Pushgateway
+  flux check --pushgateway-url http://pushgateway.monitoring:9091 --pushgateway-job flux-check
+
+  # Print the version and image of each controller as YAML for inventory tracking
+  flux check --output yaml --show-versions-only
+
+  # Validate the Flux manifests of a directory against the CRD schemas, without a cluster
+  flux check --validate-manifests ./clusters/my-cluster
+
+  # Validate the Flux manifests of a directory against the CRDs in another directory
+  flux check --validate-manifests ./clusters/my-cluster --crd-dir ./crds
 `,
 	RunE: runCheckCmd,
 }
 
 type checkFlags struct {
 	pre             bool
+	failFast        bool
+	strict          bool
+	summaryOnly     bool
 	components      []string
 	extraComponents []string
+	output          string
+
+	expectedReplicas map[string]int
+	namespaceLabels  map[string]string
+
+	outputFile       string
+	outputFileFormat string
+
+	tolerateVersionMismatch bool
+
+	pushgatewayURL string
+	pushgatewayJob string
+
+	checkDeploymentStrategy bool
+	checkMetrics            bool
+	checkResources          bool
+	showControllerFlags     bool
+	showControllerEnv       bool
+	showLogLevel            bool
+	sinceInstall            bool
+	checkProbes             bool
+	checkSecurityContext    bool
+	checkImagePolicy        bool
+	checkHA                 bool
+	showVersionsOnly        bool
+	controllerRestartCount  int
+
+	validateManifests string
+	crdDir            string
 }
 
 type kubectlVersion struct {
@@ -57,122 +199,405 @@ type kubectlVersion struct {
 
 var checkArgs checkFlags
 
+var supportedCheckOutputFormats = []string{"csv", "json", "yaml", "junit", "github-annotations"}
+
 func init() {
 	checkCmd.Flags().BoolVarP(&checkArgs.pre, "pre", "", false,
 		"only run pre-installation checks")
+	checkCmd.Flags().BoolVar(&checkArgs.failFast, "fail-fast", false,
+		"stop at the first failed check instead of running all the checks")
+	checkCmd.Flags().BoolVar(&checkArgs.strict, "strict", false,
+		"treat warnings as failures")
+	checkCmd.Flags().BoolVar(&checkArgs.summaryOnly, "summary-only", false,
+		"don't print the outcome of each check, print the number of passed, warned and failed checks of each category at the end")
+	checkCmd.Flags().BoolVar(&checkArgs.tolerateVersionMismatch, "tolerate-version-mismatch", false,
+		"report kubectl and Kubernetes versions outside of the supported range as warnings instead of failures")
 	checkCmd.Flags().StringSliceVar(&checkArgs.components, "components", rootArgs.defaults.Components,
 		"list of components, accepts comma-separated values")
 	checkCmd.Flags().StringSliceVar(&checkArgs.extraComponents, "components-extra", nil,
 		"list of components in addition to those supplied or defaulted, accepts comma-separated values")
+	checkCmd.Flags().StringToIntVar(&checkArgs.expectedReplicas, "expected-replicas", nil,
+		"fail when the desired replicas of a controller differ from the expected count, accepts comma-separated name=count values")
+	checkCmd.Flags().StringToStringVar(&checkArgs.namespaceLabels, "require-namespace-labels", nil,
+		"labels the Flux namespace must carry, as key=value pairs, can be repeated")
+	checkCmd.Flags().StringVarP(&checkArgs.output, "output", "o", "",
+		fmt.Sprintf("print the check results in the given format, available options are: (%s)", strings.Join(supportedCheckOutputFormats, ", ")))
+	checkCmd.Flags().StringVar(&checkArgs.outputFile, "output-file", "",
+		"write the check results to the given file")
+	checkCmd.Flags().StringVar(&checkArgs.pushgatewayURL, "pushgateway-url", "",
+		"push the check results as metrics to the Prometheus Pushgateway at the given URL")
+	checkCmd.Flags().StringVar(&checkArgs.pushgatewayJob, "pushgateway-job", "flux-check",
+		"the job name the metrics are pushed under, used by --pushgateway-url")
+	checkCmd.Flags().StringVar(&checkArgs.outputFileFormat, "output-file-format", "",
+		fmt.Sprintf("format of the check results written to --output-file, defaults to the --output format, available options are: (%s)", strings.Join(supportedCheckOutputFormats, ", ")))
+	checkCmd.Flags().BoolVar(&checkArgs.checkDeploymentStrategy, "check-deployment-strategy", false,
+		"warn about controllers whose deployment strategy can stop reconciliation during upgrades")
+	checkCmd.Flags().BoolVar(&checkArgs.checkMetrics, "check-metrics", false,
+		"check that the metrics endpoint of each controller returns Prometheus metrics")
+	checkCmd.Flags().BoolVar(&checkArgs.checkResources, "check-resources", false,
+		"warn about controllers without memory limits or with very low resource requests")
+	checkCmd.Flags().BoolVar(&checkArgs.showControllerFlags, "show-controller-flags", false,
+		"print the command-line flags each controller was started with")
+	checkCmd.Flags().BoolVar(&checkArgs.showControllerEnv, "show-controller-env", false,
+		"print the proxy environment variables of each controller, with credentials redacted, and warn when they differ between controllers")
+	checkCmd.Flags().BoolVar(&checkArgs.showLogLevel, "show-log-level", false,
+		"print the log level of each controller, and warn when a controller logs at debug level or the levels differ between controllers")
+	checkCmd.Flags().BoolVar(&checkArgs.sinceInstall, "since-install", false,
+		"report how long ago the controllers were installed and last updated")
+	checkCmd.Flags().BoolVar(&checkArgs.checkProbes, "check-probes", false,
+		"warn about controllers without liveness or readiness probes")
+	checkCmd.Flags().BoolVar(&checkArgs.checkSecurityContext, "check-security-context", false,
+		"warn about controllers that may run as root, have a writable root filesystem or allow privilege escalation")
+	checkCmd.Flags().BoolVar(&checkArgs.checkImagePolicy, "check-image-policy", false,
+		"warn about controller images that aren't pinned by digest, or whose mutable tag is pulled with the Always pull policy")
+	checkCmd.Flags().BoolVar(&checkArgs.checkHA, "check-ha", false,
+		"warn about controllers with more than one replica that have no pod anti-affinity or topology spread constraints, or whose pods run on the same node")
+	checkCmd.Flags().IntVar(&checkArgs.controllerRestartCount, "controller-restart-count", -1,
+		"report the restart count of each controller and warn when a container restarted more times than the given threshold, a negative value disables the check")
+	checkCmd.Flags().BoolVar(&checkArgs.showVersionsOnly, "show-versions-only", false,
+		"print only the name, version and image of each controller, requires the json or yaml output format")
+	checkCmd.Flags().StringVar(&checkArgs.validateManifests, "validate-manifests", "",
+		"validate the Flux manifests in the given directory against the CRD schemas, without connecting to the cluster")
+	checkCmd.Flags().StringVar(&checkArgs.crdDir, "crd-dir", "",
+		"directory with the CRDs used by --validate-manifests, defaults to the CRDs of the Flux release")
 	rootCmd.AddCommand(checkCmd)
 }
 
 func runCheckCmd(cmd *cobra.Command, args []string) error {
+	if checkArgs.output != "" && !utils.ContainsItemString(supportedCheckOutputFormats, checkArgs.output) {
+		return fmt.Errorf("unsupported output format '%s', must be one of: %s",
+			checkArgs.output, strings.Join(supportedCheckOutputFormats, ", "))
+	}
+
+	if checkArgs.outputFileFormat == "" {
+		checkArgs.outputFileFormat = checkArgs.output
+	}
+	if checkArgs.outputFile != "" {
+		if checkArgs.outputFileFormat == "" {
+			return fmt.Errorf("--output-file requires --output or --output-file-format to be set")
+		}
+		if !utils.ContainsItemString(supportedCheckOutputFormats, checkArgs.outputFileFormat) {
+			return fmt.Errorf("unsupported output file format '%s', must be one of: %s",
+				checkArgs.outputFileFormat, strings.Join(supportedCheckOutputFormats, ", "))
+		}
+	}
+
+	if checkArgs.showVersionsOnly {
+		for _, format := range []string{checkArgs.output, checkArgs.outputFileFormat} {
+			if format != "" && format != "json" && format != "yaml" {
+				return fmt.Errorf("--show-versions-only is not supported with the '%s' output format", format)
+			}
+		}
+		if checkArgs.output == "" && checkArgs.outputFile == "" {
+			return fmt.Errorf("--show-versions-only requires --output json or yaml")
+		}
+		if checkArgs.pre {
+			return fmt.Errorf("--show-versions-only can't be used with --pre")
+		}
+	}
+
+	if checkArgs.crdDir != "" && checkArgs.validateManifests == "" {
+		return fmt.Errorf("--crd-dir can only be used with --validate-manifests")
+	}
+	if checkArgs.validateManifests != "" && (checkArgs.pre || checkArgs.showVersionsOnly) {
+		return fmt.Errorf("--validate-manifests can't be used with --pre or --show-versions-only")
+	}
+
+	for name := range checkArgs.expectedReplicas {
+		if !utils.ContainsItemString(checkComponents(), name) {
+			return fmt.Errorf("expected replicas given for '%s', which is not a checked component", name)
+		}
+	}
+
+	if checkArgs.summaryOnly {
+		// the checks log to a discarded writer, finishCheck restores
+		// the logger to print the summary, and the errors returned by
+		// the checks are printed with the logger restored on return
+		checkLogger := logger
+		logger = stderrLogger{stderr: ioutil.Discard}
+		defer func() { logger = checkLogger }()
+	}
+
+	if checkArgs.validateManifests != "" {
+		report := &checkReport{}
+		logger.Actionf("validating manifests in %s", checkArgs.validateManifests)
+		ok, err := manifestsCheck(report, checkArgs.validateManifests)
+		if err != nil {
+			return err
+		}
+		return finishCheck(report, !ok, "manifests are valid")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
+	report := &checkReport{}
+
 	logger.Actionf("checking prerequisites")
 	checkFailed := false
 
-	if !kubectlCheck(ctx, ">=1.18.0") {
+	if !kubectlCheck(ctx, report, ">=1.18.0") {
 		checkFailed = true
+		if checkArgs.failFast {
+			return finishCheck(report, checkFailed, "")
+		}
 	}
 
-	if !kubernetesCheck(">=1.16.0") {
+	if !kubernetesCheck(report, ">=1.16.0") {
 		checkFailed = true
+		if checkArgs.failFast {
+			return finishCheck(report, checkFailed, "")
+		}
 	}
 
 	if checkArgs.pre {
-		if checkFailed {
-			os.Exit(1)
-		}
-		logger.Successf("prerequisites checks passed")
-		return nil
+		return finishCheck(report, checkFailed, "prerequisites checks passed")
 	}
 
 	logger.Actionf("checking controllers")
-	if !componentsCheck() {
+	if !componentsCheck(report) {
+		checkFailed = true
+		if checkArgs.failFast {
+			return finishCheck(report, checkFailed, "")
+		}
+	}
+
+	logger.Actionf("checking crds")
+	if err := crdsCheck(ctx, report); err != nil {
+		return err
+	}
+
+	if len(checkArgs.namespaceLabels) > 0 {
+		logger.Actionf("checking namespace labels")
+		ok, err := namespaceLabelsCheck(ctx, report, checkArgs.namespaceLabels)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			checkFailed = true
+			if checkArgs.failFast {
+				return finishCheck(report, checkFailed, "")
+			}
+		}
+	}
+
+	if checkArgs.checkDeploymentStrategy {
+		logger.Actionf("checking deployment strategies")
+		if err := deploymentStrategyCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.checkMetrics {
+		logger.Actionf("checking metrics endpoints")
+		ok, err := metricsCheck(ctx, report)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			checkFailed = true
+			if checkArgs.failFast {
+				return finishCheck(report, checkFailed, "")
+			}
+		}
+	}
+
+	if checkArgs.checkResources {
+		logger.Actionf("checking controller resources")
+		if err := resourcesCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.showControllerFlags {
+		logger.Actionf("checking controller flags")
+		if err := controllerFlagsCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.showControllerEnv {
+		logger.Actionf("checking controller environment")
+		if err := controllerEnvCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.showLogLevel {
+		logger.Actionf("checking controller log levels")
+		if err := logLevelCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.checkProbes {
+		logger.Actionf("checking controller probes")
+		if err := probesCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.checkSecurityContext {
+		logger.Actionf("checking controller security contexts")
+		if err := securityContextCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.checkImagePolicy {
+		logger.Actionf("checking controller image policies")
+		if err := imagePolicyCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.checkHA {
+		logger.Actionf("checking controller high availability")
+		if err := haCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.controllerRestartCount >= 0 {
+		logger.Actionf("checking controller restarts")
+		if err := restartCountCheck(ctx, report, checkArgs.controllerRestartCount); err != nil {
+			return err
+		}
+	}
+
+	if checkArgs.sinceInstall {
+		if err := installAgeCheck(ctx, report); err != nil {
+			return err
+		}
+	}
+	return finishCheck(report, checkFailed, "all checks passed")
+}
+
+// finishCheck prints the report in the requested output format,
+// writes it to the output file if one is given, and exits with a
+// non-zero code if any of the checks failed, or with --strict if any
+// of the checks reported a warning.
+func finishCheck(report *checkReport, checkFailed bool, successMessage string) error {
+	if checkArgs.strict && report.hasWarnings() {
 		checkFailed = true
 	}
+	if checkArgs.summaryOnly {
+		logger = stderrLogger{stderr: os.Stderr}
+		report.printSummary()
+	}
+	if checkArgs.output != "" {
+		if err := report.print(os.Stdout, checkArgs.output); err != nil {
+			return err
+		}
+	}
+	if checkArgs.pushgatewayURL != "" {
+		// a failed push is reported without changing the outcome of the checks
+		if err := report.push(checkArgs.pushgatewayURL, checkArgs.pushgatewayJob, !checkFailed); err != nil {
+			logger.Warningf("pushing metrics to %s failed: %s", checkArgs.pushgatewayURL, err.Error())
+		}
+	}
+	if checkArgs.outputFile != "" {
+		f, err := os.Create(checkArgs.outputFile)
+		if err != nil {
+			return err
+		}
+		if err := report.print(f, checkArgs.outputFileFormat); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
 	if checkFailed {
 		os.Exit(1)
 	}
-	logger.Successf("all checks passed")
+	logger.Successf(successMessage)
 	return nil
 }
 
-func kubectlCheck(ctx context.Context, version string) bool {
+func kubectlCheck(ctx context.Context, report *checkReport, version string) bool {
 	_, err := exec.LookPath("kubectl")
 	if err != nil {
-		logger.Failuref("kubectl not found")
+		report.fail(checkCategoryPrerequisites, "kubectl", "", "kubectl not found")
 		return false
 	}
 
 	kubectlArgs := []string{"version", "--client", "--output", "json"}
 	output, err := utils.ExecKubectlCommand(ctx, utils.ModeCapture, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...)
 	if err != nil {
-		logger.Failuref("kubectl version can't be determined")
+		report.fail(checkCategoryPrerequisites, "kubectl", "", "kubectl version can't be determined")
 		return false
 	}
 
 	kv := &kubectlVersion{}
 	if err = json.Unmarshal([]byte(output), kv); err != nil {
-		logger.Failuref("kubectl version output can't be unmarshaled")
+		report.fail(checkCategoryPrerequisites, "kubectl", "", "kubectl version output can't be unmarshaled")
 		return false
 	}
 
 	v, err := semver.ParseTolerant(kv.ClientVersion.GitVersion)
 	if err != nil {
-		logger.Failuref("kubectl version can't be parsed")
+		report.fail(checkCategoryPrerequisites, "kubectl", "", "kubectl version can't be parsed")
 		return false
 	}
 
 	rng, _ := semver.ParseRange(version)
 	if !rng(v) {
-		logger.Failuref("kubectl version must be %s", version)
+		if checkArgs.tolerateVersionMismatch {
+			report.warn(checkCategoryPrerequisites, "kubectl", v.String(), "kubectl version %s should be %s", v.String(), version)
+			// with --strict the warning fails the run, so --fail-fast stops here
+			return !checkArgs.strict
+		}
+		report.fail(checkCategoryPrerequisites, "kubectl", v.String(), "kubectl version must be %s", version)
 		return false
 	}
 
-	logger.Successf("kubectl %s %s", v.String(), version)
+	report.pass(checkCategoryPrerequisites, "kubectl", v.String(), "kubectl %s %s", v.String(), version)
 	return true
 }
 
-func kubernetesCheck(version string) bool {
+func kubernetesCheck(report *checkReport, version string) bool {
 	cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext)
 	if err != nil {
-		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
+		report.fail(checkCategoryPrerequisites, "kubernetes", "", "Kubernetes client initialization failed: %s", err.Error())
 		return false
 	}
 
 	client, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
-		logger.Failuref("Kubernetes client initialization failed: %s", err.Error())
+		report.fail(checkCategoryPrerequisites, "kubernetes", "", "Kubernetes client initialization failed: %s", err.Error())
 		return false
 	}
 
 	ver, err := client.Discovery().ServerVersion()
 	if err != nil {
-		logger.Failuref("Kubernetes API call failed: %s", err.Error())
+		report.fail(checkCategoryPrerequisites, "kubernetes", "", "Kubernetes API call failed: %s", err.Error())
 		return false
 	}
 
 	v, err := semver.ParseTolerant(ver.String())
 	if err != nil {
-		logger.Failuref("Kubernetes version can't be determined")
+		report.fail(checkCategoryPrerequisites, "kubernetes", "", "Kubernetes version can't be determined")
 		return false
 	}
 
 	rng, _ := semver.ParseRange(version)
 	if !rng(v) {
-		logger.Failuref("Kubernetes version must be %s", version)
+		if checkArgs.tolerateVersionMismatch {
+			report.warn(checkCategoryPrerequisites, "kubernetes", v.String(), "Kubernetes version %s should be %s", v.String(), version)
+			// with --strict the warning fails the run, so --fail-fast stops here
+			return !checkArgs.strict
+		}
+		report.fail(checkCategoryPrerequisites, "kubernetes", v.String(), "Kubernetes version must be %s", version)
 		return false
 	}
 
-	logger.Successf("Kubernetes %s %s", v.String(), version)
+	report.pass(checkCategoryPrerequisites, "kubernetes", v.String(), "Kubernetes %s %s", v.String(), version)
 	return true
 }
 
-func componentsCheck() bool {
+func componentsCheck(report *checkReport) bool {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -181,19 +606,1184 @@ func componentsCheck() bool {
 		return false
 	}
 
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return false
+	}
+
 	ok := true
-	deployments := append(checkArgs.components, checkArgs.extraComponents...)
-	for _, deployment := range deployments {
-		if err := statusChecker.Assess(deployment); err != nil {
+	for _, deployment := range checkComponents() {
+		var image string
+		kubectlArgs := []string{"-n", rootArgs.namespace, "get", "deployment", deployment, "-o", "jsonpath=\"{..image}\""}
+		if output, err := utils.ExecKubectlCommand(ctx, utils.ModeCapture, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err == nil {
+			image = strings.TrimPrefix(strings.TrimSuffix(output, "\""), "\"")
+		}
+
+		err := statusChecker.Assess(deployment)
+		report.health = append(report.health, componentHealth(ctx, kubeClient, deployment, image, err == nil))
+		if err != nil {
 			ok = false
+			report.record(checkCategoryControllers, deployment, checkStatusFail, imageTag(image), err.Error())
+		} else if expected, found := checkArgs.expectedReplicas[deployment]; found {
+			if !replicasCheck(ctx, kubeClient, report, deployment, imageTag(image), expected) {
+				ok = false
+			}
 		} else {
-			logger.Successf("%s: healthy", deployment)
+			report.pass(checkCategoryControllers, deployment, imageTag(image), "%s: healthy", deployment)
 		}
 
-		kubectlArgs := []string{"-n", rootArgs.namespace, "get", "deployment", deployment, "-o", "jsonpath=\"{..image}\""}
-		if output, err := utils.ExecKubectlCommand(ctx, utils.ModeCapture, rootArgs.kubeconfig, rootArgs.kubecontext, kubectlArgs...); err == nil {
-			logger.Actionf(strings.TrimPrefix(strings.TrimSuffix(output, "\""), "\""))
+		if image != "" {
+			logger.Actionf(image)
 		}
+		report.components = append(report.components, checkComponent{
+			Name:    deployment,
+			Version: imageTag(image),
+			Image:   image,
+		})
 	}
 	return ok
 }
+
+// componentHealth returns the replicas and conditions of the
+// controller deployment. They are left empty if the deployment can't
+// be fetched, componentsCheck reports it as not ready.
+func componentHealth(ctx context.Context, kubeClient client.Client, name, image string, ready bool) checkComponentHealth {
+	health := checkComponentHealth{
+		Name:       name,
+		Ready:      ready,
+		Image:      image,
+		Version:    imageTag(image),
+		Conditions: []checkComponentCondition{},
+	}
+
+	var deployment appsv1.Deployment
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	if err := kubeClient.Get(ctx, namespacedName, &deployment); err != nil {
+		return health
+	}
+
+	health.Replicas.Desired = 1
+	if deployment.Spec.Replicas != nil {
+		health.Replicas.Desired = *deployment.Spec.Replicas
+	}
+	health.Replicas.Ready = deployment.Status.ReadyReplicas
+	for _, c := range deployment.Status.Conditions {
+		health.Conditions = append(health.Conditions, checkComponentCondition{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+	}
+	return health
+}
+
+// replicasCheck verifies that the desired replicas of the controller
+// deployment match the expected count.
+func replicasCheck(ctx context.Context, kubeClient client.Client, report *checkReport, name, version string, expected int) bool {
+	var deployment appsv1.Deployment
+	namespacedName := types.NamespacedName{
+		Namespace: rootArgs.namespace,
+		Name:      name,
+	}
+	if err := kubeClient.Get(ctx, namespacedName, &deployment); err != nil {
+		report.fail(checkCategoryControllers, name, version, "%s: replicas can't be determined: %s", name, err.Error())
+		return false
+	}
+
+	replicas := 1
+	if deployment.Spec.Replicas != nil {
+		replicas = int(*deployment.Spec.Replicas)
+	}
+	if replicas != expected {
+		report.fail(checkCategoryControllers, name, version, "%s: %d replicas, expected %d", name, replicas, expected)
+		return false
+	}
+	report.pass(checkCategoryControllers, name, version, "%s: healthy (%d replicas)", name, replicas)
+	return true
+}
+
+// checkComponents returns the components selected for checking.
+func checkComponents() []string {
+	var components []string
+	components = append(components, checkArgs.components...)
+	return append(components, checkArgs.extraComponents...)
+}
+
+// forEachComponentDeployment calls fn with the deployment of each
+// checked component. Components whose deployment can't be fetched
+// are skipped, as componentsCheck already reports them.
+func forEachComponentDeployment(ctx context.Context, fn func(deployment appsv1.Deployment)) error {
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return err
+	}
+
+	for _, component := range checkComponents() {
+		namespacedName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      component,
+		}
+		var deployment appsv1.Deployment
+		if err := kubeClient.Get(ctx, namespacedName, &deployment); err != nil {
+			continue
+		}
+		fn(deployment)
+	}
+	return nil
+}
+
+// namespaceLabelsCheck fails if the Flux namespace is missing any of
+// the required labels, or has a different value for them.
+func namespaceLabelsCheck(ctx context.Context, report *checkReport, required map[string]string) (bool, error) {
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return false, err
+	}
+
+	var namespace corev1.Namespace
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: rootArgs.namespace}, &namespace); err != nil {
+		return false, err
+	}
+
+	var keys []string
+	for key := range required {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ok := true
+	for _, key := range keys {
+		value, found := namespace.Labels[key]
+		switch {
+		case !found:
+			ok = false
+			report.fail(checkCategoryNamespace, key, "", "%s: label %s is missing", namespace.Name, key)
+		case value != required[key]:
+			ok = false
+			report.fail(checkCategoryNamespace, key, "", "%s: label %s is '%s', expected '%s'", namespace.Name, key, value, required[key])
+		default:
+			report.pass(checkCategoryNamespace, key, "", "%s: label %s=%s", namespace.Name, key, value)
+		}
+	}
+	return ok, nil
+}
+
+// deploymentStrategyCheck warns about controllers that may be left
+// without a running replica while their deployment is rolled out.
+func deploymentStrategyCheck(ctx context.Context, report *checkReport) error {
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		name := deployment.Name
+		strategy := deployment.Spec.Strategy
+		if strategy.Type == appsv1.RecreateDeploymentStrategyType {
+			report.warn(checkCategoryDeploymentStrategy, name, "",
+				"%s: %s strategy stops reconciliation during upgrades", name, strategy.Type)
+			return
+		}
+
+		replicas := 1
+		if deployment.Spec.Replicas != nil {
+			replicas = int(*deployment.Spec.Replicas)
+		}
+		maxSurge := intstr.FromString("25%")
+		maxUnavailable := intstr.FromString("25%")
+		if strategy.RollingUpdate != nil {
+			if strategy.RollingUpdate.MaxSurge != nil {
+				maxSurge = *strategy.RollingUpdate.MaxSurge
+			}
+			if strategy.RollingUpdate.MaxUnavailable != nil {
+				maxUnavailable = *strategy.RollingUpdate.MaxUnavailable
+			}
+		}
+
+		unavailable, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, replicas, false)
+		if err != nil {
+			report.warn(checkCategoryDeploymentStrategy, name, "",
+				"%s: invalid maxUnavailable '%s'", name, maxUnavailable.String())
+			return
+		}
+		if unavailable >= replicas {
+			report.warn(checkCategoryDeploymentStrategy, name, "",
+				"%s: %s strategy with maxUnavailable %s can leave no replica running during upgrades",
+				name, appsv1.RollingUpdateDeploymentStrategyType, maxUnavailable.String())
+			return
+		}
+		report.pass(checkCategoryDeploymentStrategy, name, "", "%s: %s strategy (maxSurge: %s, maxUnavailable: %s)",
+			name, appsv1.RollingUpdateDeploymentStrategyType, maxSurge.String(), maxUnavailable.String())
+	})
+}
+
+// crdAPIVersions holds the API version this CLI was built for, for
+// each of the toolkit API groups.
+var crdAPIVersions = map[string]string{
+	sourcev1.GroupVersion.Group:       sourcev1.GroupVersion.Version,
+	kustomizev1.GroupVersion.Group:    kustomizev1.GroupVersion.Version,
+	helmv2.GroupVersion.Group:         helmv2.GroupVersion.Version,
+	notificationv1.GroupVersion.Group: notificationv1.GroupVersion.Version,
+	imagereflectv1.GroupVersion.Group: imagereflectv1.GroupVersion.Version,
+	imageautov1.GroupVersion.Group:    imageautov1.GroupVersion.Version,
+}
+
+// crdsCheck reports the versions served by the installed toolkit
+// CRDs, and warns when a CRD does not serve the API version this
+// CLI expects, or when no CRD of a toolkit API group is installed.
+// CRDs are cluster-scoped, a failed listing is reported as a warning
+// so that users without access to them still get the other checks.
+func crdsCheck(ctx context.Context, report *checkReport) error {
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return err
+	}
+
+	var list apiextensionsv1.CustomResourceDefinitionList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		report.warn(checkCategoryCRDs, "crds", "", "CRDs can't be listed: %s", err.Error())
+		return nil
+	}
+
+	found := map[string]bool{}
+	for _, crd := range list.Items {
+		expected, ok := crdAPIVersions[crd.Spec.Group]
+		if !ok {
+			continue
+		}
+		found[crd.Spec.Group] = true
+
+		var served []string
+		var storage string
+		for _, version := range crd.Spec.Versions {
+			if version.Served {
+				served = append(served, version.Name)
+			}
+			if version.Storage {
+				storage = version.Name
+			}
+		}
+
+		if !utils.ContainsItemString(served, expected) {
+			report.warn(checkCategoryCRDs, crd.Name, storage, "%s: serves %s, but this CLI expects %s",
+				crd.Name, strings.Join(served, ", "), expected)
+			continue
+		}
+		report.pass(checkCategoryCRDs, crd.Name, storage, "%s: %s", crd.Name, strings.Join(served, ", "))
+	}
+
+	var groups []string
+	for group := range crdAPIVersions {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		if !found[group] {
+			report.warn(checkCategoryCRDs, group, "", "%s: no CRDs installed, this CLI expects %s", group, crdAPIVersions[group])
+		}
+	}
+	return nil
+}
+
+// installAgeCheck reports how long ago the oldest controller
+// deployment was created, and how long ago the most recent rollout of
+// a controller happened.
+func installAgeCheck(ctx context.Context, report *checkReport) error {
+	var installed, updated time.Time
+	err := forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		created := deployment.CreationTimestamp.Time
+		if installed.IsZero() || created.Before(installed) {
+			installed = created
+		}
+		lastUpdate := created
+		for _, c := range deployment.Status.Conditions {
+			if c.Type == appsv1.DeploymentProgressing && c.LastUpdateTime.After(lastUpdate) {
+				lastUpdate = c.LastUpdateTime.Time
+			}
+		}
+		if lastUpdate.After(updated) {
+			updated = lastUpdate
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if installed.IsZero() {
+		return nil
+	}
+
+	report.pass(checkCategoryInstall, "flux", "", "Flux installed %s ago, controllers last updated %s ago",
+		duration.HumanDuration(time.Since(installed)), duration.HumanDuration(time.Since(updated)))
+	return nil
+}
+
+// probesCheck warns about controller containers without a liveness
+// or a readiness probe, or with a probe that has no handler.
+func probesCheck(ctx context.Context, report *checkReport) error {
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+
+			var problems []string
+			for probeType, probe := range map[string]*corev1.Probe{
+				"liveness":  container.LivenessProbe,
+				"readiness": container.ReadinessProbe,
+			} {
+				switch {
+				case probe == nil:
+					problems = append(problems, fmt.Sprintf("no %s probe", probeType))
+				case probe.Exec == nil && probe.HTTPGet == nil && probe.TCPSocket == nil:
+					problems = append(problems, fmt.Sprintf("%s probe has no handler", probeType))
+				}
+			}
+			sort.Strings(problems)
+
+			if len(problems) > 0 {
+				report.warn(checkCategoryProbes, name, "", "%s: %s", name, strings.Join(problems, ", "))
+				continue
+			}
+			report.pass(checkCategoryProbes, name, "", "%s: liveness and readiness probes configured", name)
+		}
+	})
+}
+
+// restartCountCheck reports the restart count of each controller
+// container, summed over the pods of its deployment, and warns when it
+// exceeds the threshold. This catches controllers that are crash-looping
+// while passing a point-in-time readiness check.
+func restartCountCheck(ctx context.Context, report *checkReport, threshold int) error {
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return err
+	}
+
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		var pods corev1.PodList
+		if err := kubeClient.List(ctx, &pods, client.InNamespace(deployment.Namespace),
+			client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil || len(pods.Items) == 0 {
+			report.warn(checkCategoryRestarts, deployment.Name, "", "%s: no pods found", deployment.Name)
+			return
+		}
+
+		restarts := map[string]int{}
+		for _, pod := range pods.Items {
+			for _, status := range pod.Status.ContainerStatuses {
+				restarts[status.Name] += int(status.RestartCount)
+			}
+		}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+			count := restarts[container.Name]
+			if count > threshold {
+				report.warn(checkCategoryRestarts, name, "", "%s: %d restarts, more than %d", name, count, threshold)
+				continue
+			}
+			report.pass(checkCategoryRestarts, name, "", "%s: %d restarts", name, count)
+		}
+	})
+}
+
+// haCheck verifies that the controllers running more than one replica
+// keep their pods apart, with pod anti-affinity or topology spread
+// constraints, and reports nodes running several of their pods.
+// Controllers with a single replica are skipped.
+func haCheck(ctx context.Context, report *checkReport) error {
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return err
+	}
+
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if replicas < 2 {
+			return
+		}
+
+		podSpec := deployment.Spec.Template.Spec
+		spread := len(podSpec.TopologySpreadConstraints) > 0
+		if a := podSpec.Affinity; a != nil && a.PodAntiAffinity != nil {
+			spread = spread || len(a.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 ||
+				len(a.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0
+		}
+
+		var problems []string
+		if !spread {
+			problems = append(problems, "no pod anti-affinity or topology spread constraints, the replicas can be scheduled on the same node")
+		}
+
+		var pods corev1.PodList
+		if err := kubeClient.List(ctx, &pods, client.InNamespace(deployment.Namespace),
+			client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err == nil {
+			podsPerNode := map[string]int{}
+			for _, pod := range pods.Items {
+				if pod.Spec.NodeName != "" {
+					podsPerNode[pod.Spec.NodeName]++
+				}
+			}
+			var nodes []string
+			for node, count := range podsPerNode {
+				if count > 1 {
+					nodes = append(nodes, fmt.Sprintf("%s (%d pods)", node, count))
+				}
+			}
+			sort.Strings(nodes)
+			if len(nodes) > 0 {
+				problems = append(problems, fmt.Sprintf("replicas are running on the same node: %s", strings.Join(nodes, ", ")))
+			}
+		}
+
+		if len(problems) > 0 {
+			report.warn(checkCategoryHA, deployment.Name, "", "%s: %d replicas, %s", deployment.Name, replicas, strings.Join(problems, ", "))
+			return
+		}
+		report.pass(checkCategoryHA, deployment.Name, "", "%s: %d replicas spread across nodes", deployment.Name, replicas)
+	})
+}
+
+// controllerFlagsCheck reports the arguments of each controller
+// container, to confirm install customizations have been applied.
+func controllerFlagsCheck(ctx context.Context, report *checkReport) error {
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+			args := strings.Join(append(container.Command, container.Args...), " ")
+			if args == "" {
+				args = "no flags"
+			}
+			report.pass(checkCategoryControllerFlags, name, "", "%s: %s", name, args)
+		}
+	})
+}
+
+// securityContextCheck warns about controller containers that are not
+// enforced to run as non-root, have a writable root filesystem, or
+// can gain more privileges than their parent process. The container
+// security context takes precedence over the pod security context.
+func securityContextCheck(ctx context.Context, report *checkReport) error {
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		podContext := deployment.Spec.Template.Spec.SecurityContext
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+			sc := container.SecurityContext
+			if sc == nil {
+				sc = &corev1.SecurityContext{}
+			}
+
+			runAsNonRoot := sc.RunAsNonRoot
+			if runAsNonRoot == nil && podContext != nil {
+				runAsNonRoot = podContext.RunAsNonRoot
+			}
+
+			var problems []string
+			switch {
+			case runAsNonRoot == nil:
+				problems = append(problems, "runAsNonRoot is not set")
+			case !*runAsNonRoot:
+				problems = append(problems, "runAsNonRoot is false")
+			}
+			if sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
+				problems = append(problems, "readOnlyRootFilesystem is not enabled")
+			}
+			if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+				problems = append(problems, "privilege escalation is allowed")
+			}
+			if sc.Privileged != nil && *sc.Privileged {
+				problems = append(problems, "container is privileged")
+			}
+
+			if len(problems) > 0 {
+				report.warn(checkCategorySecurityContext, name, "", "%s: %s", name, strings.Join(problems, ", "))
+				continue
+			}
+			report.pass(checkCategorySecurityContext, name, "", "%s: runs as non-root with a read-only root filesystem", name)
+		}
+	})
+}
+
+// imagePolicyCheck warns about controller containers whose image is
+// not pinned by digest, that pull a mutable tag on every start, or that
+// pull a digest-pinned image more often than needed.
+func imagePolicyCheck(ctx context.Context, report *checkReport) error {
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+
+			pinned := strings.Contains(container.Image, "@")
+			tag := imageTag(strings.SplitN(container.Image, "@", 2)[0])
+			mutable := !pinned && (tag == "" || tag == "latest")
+			policy := container.ImagePullPolicy
+			if policy == "" {
+				// the API server default
+				policy = corev1.PullIfNotPresent
+				if mutable {
+					policy = corev1.PullAlways
+				}
+			}
+
+			var problems []string
+			if mutable && policy == corev1.PullAlways {
+				// an image without a tag is pulled as latest
+				problems = append(problems, fmt.Sprintf("mutable tag 'latest' is pulled with the %s policy, a restart can upgrade the controller", policy))
+			}
+			if !pinned {
+				problems = append(problems, "image is not pinned by digest")
+			} else if policy != corev1.PullIfNotPresent {
+				problems = append(problems, fmt.Sprintf("image is pinned by digest but pulled with the %s policy instead of %s", policy, corev1.PullIfNotPresent))
+			}
+
+			if len(problems) > 0 {
+				report.warn(checkCategoryImagePolicy, name, tag, "%s: %s (%s)", name, strings.Join(problems, ", "), container.Image)
+				continue
+			}
+			report.pass(checkCategoryImagePolicy, name, tag, "%s: %s pinned by digest, pull policy %s", name, container.Image, policy)
+		}
+	})
+}
+
+// controllerEnvVars are the environment variables controlling the
+// proxy used by the controllers to reach Git, Helm and OCI servers.
+var controllerEnvVars = []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY", "ALL_PROXY"}
+
+// controllerEnvCheck prints the proxy environment variables set on each
+// controller container, and warns when the controllers don't share the
+// same proxy settings.
+func controllerEnvCheck(ctx context.Context, report *checkReport) error {
+	settings := map[string]string{}
+	err := forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+			var vars []string
+			for _, env := range container.Env {
+				if !utils.ContainsItemString(controllerEnvVars, strings.ToUpper(env.Name)) {
+					continue
+				}
+				vars = append(vars, fmt.Sprintf("%s=%s", env.Name, controllerEnvValue(env)))
+			}
+			sort.Strings(vars)
+			summary := strings.Join(vars, " ")
+			if summary == "" {
+				summary = "no proxy environment variables"
+			}
+			settings[name] = summary
+			report.pass(checkCategoryControllerEnv, name, "", "%s: %s", name, summary)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	distinct := map[string]bool{}
+	for _, summary := range settings {
+		distinct[summary] = true
+	}
+	if len(distinct) > 1 {
+		report.warn(checkCategoryControllerEnv, "proxy", "", "the controllers don't share the same proxy environment variables")
+	}
+	return nil
+}
+
+// defaultLogLevel is the log level of the controllers started without
+// the --log-level flag.
+const defaultLogLevel = "info"
+
+// logLevelCheck reports the --log-level flag of each controller
+// container. It warns about the controllers logging at debug or trace
+// level, which is verbose and slows reconciliation down, and when the
+// controllers don't share the same log level.
+func logLevelCheck(ctx context.Context, report *checkReport) error {
+	levels := map[string]bool{}
+	err := forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+			level := containerLogLevel(append(container.Command, container.Args...))
+			levels[level] = true
+			switch level {
+			case "debug", "trace":
+				report.warn(checkCategoryLogLevel, name, "", "%s: log level is %s", name, level)
+			default:
+				report.pass(checkCategoryLogLevel, name, "", "%s: log level is %s", name, level)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(levels) > 1 {
+		report.warn(checkCategoryLogLevel, "log-level", "", "the controllers don't share the same log level")
+	}
+	return nil
+}
+
+// containerLogLevel returns the value of the --log-level flag in the
+// container arguments, or the default level if the flag is not set.
+func containerLogLevel(args []string) string {
+	level := defaultLogLevel
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		switch {
+		case strings.HasPrefix(name, "log-level="):
+			level = strings.TrimPrefix(name, "log-level=")
+		case name == "log-level" && i+1 < len(args):
+			level = args[i+1]
+		}
+	}
+	return strings.ToLower(level)
+}
+
+// controllerEnvValue returns the value of the environment variable,
+// with the password of proxy URLs redacted. Values taken from secrets
+// or config maps are printed as a reference.
+func controllerEnvValue(env corev1.EnvVar) string {
+	if from := env.ValueFrom; from != nil {
+		switch {
+		case from.SecretKeyRef != nil:
+			return fmt.Sprintf("<secret %s/%s>", from.SecretKeyRef.Name, from.SecretKeyRef.Key)
+		case from.ConfigMapKeyRef != nil:
+			return fmt.Sprintf("<configmap %s/%s>", from.ConfigMapKeyRef.Name, from.ConfigMapKeyRef.Key)
+		}
+		return "<from field>"
+	}
+	if u, err := url.Parse(env.Value); err == nil && u.User != nil {
+		// a user without password is likely a token
+		if _, ok := u.User.Password(); !ok {
+			u.User = url.User("xxxxx")
+			return u.String()
+		}
+		return u.Redacted()
+	}
+	return env.Value
+}
+
+// minCPURequest and minMemoryRequest are the requests below which a
+// controller is likely to be throttled or OOMKilled.
+var (
+	minCPURequest    = apiresource.MustParse("10m")
+	minMemoryRequest = apiresource.MustParse("32Mi")
+)
+
+// resourcesCheck reports the resource requests and limits of the
+// controllers, and warns when the memory limit is unset or the
+// requests are suspiciously low.
+func resourcesCheck(ctx context.Context, report *checkReport) error {
+	return forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			name := deployment.Name
+			if len(deployment.Spec.Template.Spec.Containers) > 1 {
+				name = fmt.Sprintf("%s/%s", deployment.Name, container.Name)
+			}
+			requests := container.Resources.Requests
+			limits := container.Resources.Limits
+			summary := fmt.Sprintf("requests (cpu: %s, memory: %s), limits (cpu: %s, memory: %s)",
+				resourceString(requests, corev1.ResourceCPU), resourceString(requests, corev1.ResourceMemory),
+				resourceString(limits, corev1.ResourceCPU), resourceString(limits, corev1.ResourceMemory))
+
+			var problems []string
+			if _, ok := limits[corev1.ResourceMemory]; !ok {
+				problems = append(problems, "no memory limit")
+			}
+			if cpu, ok := requests[corev1.ResourceCPU]; ok && cpu.Cmp(minCPURequest) < 0 {
+				problems = append(problems, fmt.Sprintf("cpu request below %s", minCPURequest.String()))
+			}
+			if memory, ok := requests[corev1.ResourceMemory]; ok && memory.Cmp(minMemoryRequest) < 0 {
+				problems = append(problems, fmt.Sprintf("memory request below %s", minMemoryRequest.String()))
+			}
+
+			if len(problems) > 0 {
+				report.warn(checkCategoryResources, name, "", "%s: %s, %s", name, strings.Join(problems, ", "), summary)
+				continue
+			}
+			report.pass(checkCategoryResources, name, "", "%s: %s", name, summary)
+		}
+	})
+}
+
+func resourceString(resources corev1.ResourceList, name corev1.ResourceName) string {
+	if quantity, ok := resources[name]; ok {
+		return quantity.String()
+	}
+	return "unset"
+}
+
+// metricsPortName is the name of the container port the controllers
+// expose their Prometheus metrics on.
+const metricsPortName = "http-prom"
+
+// metricsCheck scrapes the metrics endpoint of a pod of each
+// controller through the API server proxy, and fails for controllers
+// whose endpoint can't be reached or doesn't return any metrics.
+func metricsCheck(ctx context.Context, report *checkReport) (bool, error) {
+	cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return false, err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return false, err
+	}
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return false, err
+	}
+
+	ok := true
+	err = forEachComponentDeployment(ctx, func(deployment appsv1.Deployment) {
+		name := deployment.Name
+		port := "8080"
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			for _, p := range container.Ports {
+				if p.Name == metricsPortName {
+					port = strconv.Itoa(int(p.ContainerPort))
+				}
+			}
+		}
+
+		var pods corev1.PodList
+		if err := kubeClient.List(ctx, &pods, client.InNamespace(deployment.Namespace),
+			client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil || len(pods.Items) == 0 {
+			ok = false
+			report.fail(checkCategoryMetrics, name, "", "%s: no pods found to scrape", name)
+			return
+		}
+		pod := pods.Items[0].Name
+
+		data, err := clientset.CoreV1().Pods(deployment.Namespace).ProxyGet("http", pod, port, "/metrics", nil).DoRaw(ctx)
+		if err != nil {
+			ok = false
+			report.fail(checkCategoryMetrics, name, "", "%s: metrics endpoint unreachable: %s", name, err.Error())
+			return
+		}
+		samples, err := countMetricSamples(data)
+		if err != nil {
+			ok = false
+			report.fail(checkCategoryMetrics, name, "", "%s: metrics can't be parsed: %s", name, err.Error())
+			return
+		}
+		if samples == 0 {
+			ok = false
+			report.fail(checkCategoryMetrics, name, "", "%s: metrics endpoint returned no samples", name)
+			return
+		}
+		report.pass(checkCategoryMetrics, name, "", "%s: %d metric samples", name, samples)
+	})
+	return ok, err
+}
+
+// countMetricSamples returns the number of samples in a Prometheus
+// text exposition, or an error if a sample has no valid value.
+func countMetricSamples(data []byte) (int, error) {
+	samples := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rest := line
+		if i := strings.LastIndex(rest, "}"); i >= 0 {
+			rest = rest[i+1:]
+		} else if i := strings.Index(rest, " "); i >= 0 {
+			rest = rest[i:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return samples, fmt.Errorf("sample '%s' has no value", line)
+		}
+		if _, err := strconv.ParseFloat(fields[0], 64); err != nil {
+			return samples, fmt.Errorf("sample '%s' has an invalid value", line)
+		}
+		samples++
+	}
+	return samples, nil
+}
+
+// imageTag returns the tag of a container image reference, or an
+// empty string if the reference is untagged.
+func imageTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+const (
+	checkCategoryPrerequisites = "prerequisites"
+	checkCategoryControllers   = "controllers"
+	checkCategoryCRDs          = "crds"
+	checkCategoryMetrics       = "metrics"
+	checkCategoryResources     = "resources"
+	checkCategoryNamespace     = "namespace"
+
+	checkCategoryControllerFlags = "controller-flags"
+	checkCategoryControllerEnv   = "controller-env"
+	checkCategoryLogLevel        = "log-level"
+	checkCategoryInstall         = "install"
+	checkCategoryProbes          = "probes"
+	checkCategorySecurityContext = "security-context"
+	checkCategoryImagePolicy     = "image-policy"
+	checkCategoryRestarts        = "restarts"
+	checkCategoryHA              = "ha"
+	checkCategoryManifests       = "manifests"
+
+	checkCategoryDeploymentStrategy = "deployment-strategy"
+
+	checkStatusPass = "pass"
+	checkStatusWarn = "warn"
+	checkStatusFail = "fail"
+)
+
+type checkResult struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail"`
+	Version  string `json:"version,omitempty"`
+}
+
+// checkReport collects the outcome of every check, so the results
+// can be printed in a machine-readable format once all checks ran.
+type checkReport struct {
+	results    []checkResult
+	components []checkComponent
+	health     []checkComponentHealth
+}
+
+// checkComponent is the version inventory entry of a controller,
+// printed instead of the check results with --show-versions-only.
+type checkComponent struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Image   string `json:"image"`
+}
+
+// checkComponentHealth is the health of a controller, printed in the
+// components list with --output yaml.
+type checkComponentHealth struct {
+	Name       string                    `json:"name"`
+	Ready      bool                      `json:"ready"`
+	Replicas   checkComponentReplicas    `json:"replicas"`
+	Image      string                    `json:"image"`
+	Version    string                    `json:"version"`
+	Conditions []checkComponentCondition `json:"conditions"`
+}
+
+type checkComponentReplicas struct {
+	Desired int32 `json:"desired"`
+	Ready   int32 `json:"ready"`
+}
+
+type checkComponentCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// checkYAMLReport is the document printed with --output yaml.
+type checkYAMLReport struct {
+	Components []checkComponentHealth `json:"components"`
+	Checks     []checkResult          `json:"checks"`
+}
+
+// checkVersions is the document printed with --show-versions-only.
+type checkVersions struct {
+	Components []checkComponent `json:"components"`
+}
+
+func (r *checkReport) record(category, name, status, version, detail string) {
+	r.results = append(r.results, checkResult{
+		Name:     name,
+		Category: category,
+		Status:   status,
+		Detail:   detail,
+		Version:  version,
+	})
+}
+
+func (r *checkReport) pass(category, name, version, format string, a ...interface{}) {
+	detail := fmt.Sprintf(format, a...)
+	logger.Successf("%s", detail)
+	r.record(category, name, checkStatusPass, version, detail)
+}
+
+func (r *checkReport) warn(category, name, version, format string, a ...interface{}) {
+	detail := fmt.Sprintf(format, a...)
+	logger.Warningf("%s", detail)
+	r.record(category, name, checkStatusWarn, version, detail)
+}
+
+func (r *checkReport) fail(category, name, version, format string, a ...interface{}) {
+	detail := fmt.Sprintf(format, a...)
+	logger.Failuref("%s", detail)
+	r.record(category, name, checkStatusFail, version, detail)
+}
+
+// printSummary logs a line for each category with the number of
+// passed, warned and failed checks, in the order the categories were
+// checked.
+func (r *checkReport) printSummary() {
+	var categories []string
+	counts := map[string]map[string]int{}
+	for _, res := range r.results {
+		if counts[res.Category] == nil {
+			categories = append(categories, res.Category)
+			counts[res.Category] = map[string]int{}
+		}
+		counts[res.Category][res.Status]++
+	}
+
+	for _, category := range categories {
+		c := counts[category]
+		summary := fmt.Sprintf("%s: %d passed, %d warned, %d failed",
+			category, c[checkStatusPass], c[checkStatusWarn], c[checkStatusFail])
+		switch {
+		case c[checkStatusFail] > 0:
+			logger.Failuref("%s", summary)
+		case c[checkStatusWarn] > 0:
+			logger.Warningf("%s", summary)
+		default:
+			logger.Successf("%s", summary)
+		}
+	}
+}
+
+// hasWarnings returns true if any of the checks reported a warning.
+func (r *checkReport) hasWarnings() bool {
+	for _, res := range r.results {
+		if res.Status == checkStatusWarn {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *checkReport) print(w io.Writer, format string) error {
+	if checkArgs.showVersionsOnly {
+		return r.printVersions(w, format)
+	}
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"name", "category", "status", "detail", "version"}); err != nil {
+			return err
+		}
+		for _, res := range r.results {
+			if err := cw.Write([]string{res.Name, res.Category, res.Status, res.Detail, res.Version}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		return printJSON(w, r.results)
+	case "yaml":
+		doc := checkYAMLReport{Components: r.health, Checks: r.results}
+		if doc.Components == nil {
+			doc.Components = []checkComponentHealth{}
+		}
+		if doc.Checks == nil {
+			doc.Checks = []checkResult{}
+		}
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "junit":
+		return r.printJUnit(w)
+	case "github-annotations":
+		return r.printGitHubAnnotations(w)
+	}
+	return fmt.Errorf("unsupported output format '%s'", format)
+}
+
+// printGitHubAnnotations writes a GitHub Actions workflow command for
+// each failed or warned check, so that they are shown as annotations
+// of the workflow run.
+func (r *checkReport) printGitHubAnnotations(w io.Writer) error {
+	for _, res := range r.results {
+		var command string
+		switch res.Status {
+		case checkStatusFail:
+			command = "error"
+		case checkStatusWarn:
+			command = "warning"
+		default:
+			continue
+		}
+		title := githubAnnotationProperty(fmt.Sprintf("flux check %s/%s", res.Category, res.Name))
+		if _, err := fmt.Fprintf(w, "::%s title=%s::%s\n", command, title, githubAnnotationData(res.Detail)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// githubAnnotationData escapes the message of a workflow command.
+func githubAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubAnnotationProperty escapes a property of a workflow command.
+func githubAnnotationProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(githubAnnotationData(s))
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// printMetrics writes the report in the Prometheus text format: the
+// health of each controller, whether the kubectl and Kubernetes
+// versions are supported, and the overall outcome of the checks.
+func (r *checkReport) printMetrics(w io.Writer, success bool) error {
+	var b strings.Builder
+	b.WriteString("# HELP flux_check_component_healthy Whether the Flux controller is healthy.\n")
+	b.WriteString("# TYPE flux_check_component_healthy gauge\n")
+	for _, res := range r.results {
+		if res.Category == checkCategoryControllers {
+			fmt.Fprintf(&b, "flux_check_component_healthy{component=%q,version=%q} %d\n",
+				res.Name, res.Version, metricBool(res.Status != checkStatusFail))
+		}
+	}
+	b.WriteString("# HELP flux_check_version_ok Whether the version of the prerequisite is supported.\n")
+	b.WriteString("# TYPE flux_check_version_ok gauge\n")
+	for _, res := range r.results {
+		if res.Category == checkCategoryPrerequisites {
+			fmt.Fprintf(&b, "flux_check_version_ok{name=%q,version=%q} %d\n",
+				res.Name, res.Version, metricBool(res.Status == checkStatusPass))
+		}
+	}
+	b.WriteString("# HELP flux_check_success Whether all the checks passed.\n")
+	b.WriteString("# TYPE flux_check_success gauge\n")
+	fmt.Fprintf(&b, "flux_check_success %d\n", metricBool(success))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func metricBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// push replaces the metrics of the job on the Pushgateway with the
+// metrics of the report.
+func (r *checkReport) push(gatewayURL, job string, success bool) error {
+	var body bytes.Buffer
+	if err := r.printMetrics(&body, success); err != nil {
+		return err
+	}
+	address := fmt.Sprintf("%s/metrics/job/%s", strings.TrimSuffix(gatewayURL, "/"), url.PathEscape(job))
+	req, err := http.NewRequest(http.MethodPut, address, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// printVersions prints the version inventory of the checked
+// controllers, leaving out the health details.
+func (r *checkReport) printVersions(w io.Writer, format string) error {
+	versions := checkVersions{Components: r.components}
+	if versions.Components == nil {
+		versions.Components = []checkComponent{}
+	}
+	switch format {
+	case "json":
+		return printJSON(w, versions)
+	case "yaml":
+		data, err := yaml.Marshal(versions)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return fmt.Errorf("unsupported output format '%s'", format)
+}
+
+// printJUnit writes the report as a JUnit XML test suite, with a
+// test case for each check. Failed checks are reported as failures,
+// warnings are kept in the test case output.
+func (r *checkReport) printJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:  "flux check",
+		Tests: len(r.results),
+	}
+	for _, res := range r.results {
+		testCase := junitTestCase{
+			Name:      res.Name,
+			ClassName: res.Category,
+			SystemOut: res.Detail,
+		}
+		if res.Status == checkStatusFail {
+			testCase.Failure = &junitFailure{Message: res.Detail}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
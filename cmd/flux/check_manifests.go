@@ -0,0 +1,345 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/fluxcd/flux2/pkg/manifestgen/install"
+)
+
+// manifestsCheck validates the Flux objects found in the manifests of
+// the given directory against the schemas of the Flux CRDs, without
+// connecting to a cluster. The CRDs are read from --crd-dir, or taken
+// from the install manifests of the release when no directory is given.
+func manifestsCheck(report *checkReport, dir string) (bool, error) {
+	schemas, err := loadCRDSchemas()
+	if err != nil {
+		return false, err
+	}
+
+	files, err := manifestFiles(dir)
+	if err != nil {
+		return false, err
+	}
+	if len(files) == 0 {
+		return false, fmt.Errorf("no manifests found in %s", dir)
+	}
+
+	ok := true
+	for _, file := range files {
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			name = file
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return false, err
+		}
+		objects, err := decodeManifests(data)
+		if err != nil {
+			ok = false
+			report.fail(checkCategoryManifests, name, "", "%s: %s", name, err.Error())
+			continue
+		}
+
+		var errs []string
+		validated := 0
+		for _, obj := range objects {
+			apiVersion, _ := obj["apiVersion"].(string)
+			kind, _ := obj["kind"].(string)
+			gv, err := schema.ParseGroupVersion(apiVersion)
+			if err != nil || !strings.HasSuffix(gv.Group, "fluxcd.io") {
+				continue
+			}
+			var objName string
+			if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+				objName, _ = metadata["name"].(string)
+			}
+			id := fmt.Sprintf("%s/%s", kind, objName)
+			validated++
+
+			s, found := schemas[gv.WithKind(kind)]
+			if !found {
+				errs = append(errs, fmt.Sprintf("%s: no CRD schema found for %s %s", id, apiVersion, kind))
+				continue
+			}
+			if objName == "" {
+				errs = append(errs, fmt.Sprintf("%s: metadata.name: required field is missing", id))
+			}
+			for _, e := range validateSchema("", obj, s) {
+				errs = append(errs, fmt.Sprintf("%s: %s", id, e))
+			}
+		}
+
+		if validated == 0 {
+			continue
+		}
+		if len(errs) > 0 {
+			ok = false
+			for _, e := range errs {
+				report.fail(checkCategoryManifests, name, "", "%s: %s", name, e)
+			}
+			continue
+		}
+		report.pass(checkCategoryManifests, name, "", "%s: %d objects valid", name, validated)
+	}
+	return ok, nil
+}
+
+// loadCRDSchemas returns the OpenAPI schema of each served version of
+// the Flux CRDs, indexed by group, version and kind.
+func loadCRDSchemas() (map[schema.GroupVersionKind]*apiextensionsv1.JSONSchemaProps, error) {
+	var sources [][]byte
+	if checkArgs.crdDir != "" {
+		files, err := manifestFiles(checkArgs.crdDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, data)
+		}
+	} else {
+		logger.Generatef("downloading the CRDs of Flux %s", rootArgs.defaults.Version)
+		opts := rootArgs.defaults
+		opts.Components = append(opts.Components, opts.ComponentsExtra...)
+		opts.Namespace = rootArgs.namespace
+		opts.Timeout = rootArgs.timeout
+		manifest, err := install.Generate(opts)
+		if err != nil {
+			return nil, fmt.Errorf("downloading the CRDs failed: %w", err)
+		}
+		sources = append(sources, []byte(manifest.Content))
+	}
+
+	schemas := map[schema.GroupVersionKind]*apiextensionsv1.JSONSchemaProps{}
+	for _, data := range sources {
+		objects, err := decodeManifests(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			if obj["kind"] != "CustomResourceDefinition" {
+				continue
+			}
+			raw, err := json.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			var crd apiextensionsv1.CustomResourceDefinition
+			if err := json.Unmarshal(raw, &crd); err != nil {
+				return nil, err
+			}
+			for _, v := range crd.Spec.Versions {
+				if !v.Served || v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+					continue
+				}
+				gvk := schema.GroupVersionKind{
+					Group:   crd.Spec.Group,
+					Version: v.Name,
+					Kind:    crd.Spec.Names.Kind,
+				}
+				schemas[gvk] = v.Schema.OpenAPIV3Schema
+			}
+		}
+	}
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("no CRD schemas found")
+	}
+	return schemas, nil
+}
+
+// manifestFiles returns the YAML and JSON files in the directory and
+// its subdirectories, in lexical order.
+func manifestFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// decodeManifests decodes the documents of a multi-doc YAML or JSON
+// manifest, skipping empty documents.
+func decodeManifests(data []byte) ([]map[string]interface{}, error) {
+	var objects []map[string]interface{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, err
+		}
+		if len(obj) > 0 {
+			objects = append(objects, obj)
+		}
+	}
+}
+
+// validateSchema returns the structural errors of the value against
+// the schema: mismatching types, missing required fields, unknown
+// fields and values not in an enum.
+func validateSchema(path string, value interface{}, s *apiextensionsv1.JSONSchemaProps) []string {
+	if value == nil {
+		return nil
+	}
+	field := path
+	if field == "" {
+		field = "<root>"
+	}
+
+	if s.XIntOrString {
+		switch value.(type) {
+		case string, float64, int64:
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: must be an integer or a string", field)}
+	}
+
+	var errs []string
+	switch s.Type {
+	case "object":
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be an object", field)}
+		}
+		for _, required := range s.Required {
+			if _, found := m[required]; !found {
+				errs = append(errs, fmt.Sprintf("%s: required field is missing", joinFieldPath(path, required)))
+			}
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			// the metadata is validated by the API server, not the CRD schema
+			if path == "" && k == "metadata" {
+				continue
+			}
+			if prop, found := s.Properties[k]; found {
+				errs = append(errs, validateSchema(joinFieldPath(path, k), m[k], &prop)...)
+				continue
+			}
+			if s.AdditionalProperties != nil {
+				if s.AdditionalProperties.Schema != nil {
+					errs = append(errs, validateSchema(joinFieldPath(path, k), m[k], s.AdditionalProperties.Schema)...)
+				}
+				continue
+			}
+			if s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields {
+				continue
+			}
+			if len(s.Properties) > 0 {
+				errs = append(errs, fmt.Sprintf("%s: unknown field", joinFieldPath(path, k)))
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be an array", field)}
+		}
+		if s.Items != nil && s.Items.Schema != nil {
+			for i, item := range items {
+				errs = append(errs, validateSchema(fmt.Sprintf("%s[%d]", path, i), item, s.Items.Schema)...)
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return []string{fmt.Sprintf("%s: must be a string", field)}
+		}
+	case "integer":
+		switch v := value.(type) {
+		case int64:
+		case float64:
+			if v != math.Trunc(v) {
+				return []string{fmt.Sprintf("%s: must be an integer", field)}
+			}
+		default:
+			return []string{fmt.Sprintf("%s: must be an integer", field)}
+		}
+	case "number":
+		switch value.(type) {
+		case int64, float64:
+		default:
+			return []string{fmt.Sprintf("%s: must be a number", field)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: must be a boolean", field)}
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return append(errs, fmt.Sprintf("%s: %s", field, err.Error()))
+		}
+		var allowed []string
+		found := false
+		for _, e := range s.Enum {
+			if bytes.Equal(bytes.TrimSpace(e.Raw), data) {
+				found = true
+				break
+			}
+			allowed = append(allowed, string(e.Raw))
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: unsupported value %s, must be one of: %s",
+				field, string(data), strings.Join(allowed, ", ")))
+		}
+	}
+	return errs
+}
+
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
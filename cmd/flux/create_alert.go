@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -28,8 +29,11 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
 	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 
 	"github.com/fluxcd/flux2/internal/utils"
 )
@@ -44,6 +48,15 @@ var createAlertCmd = &cobra.Command{
   --event-source Kustomization/flux-system \
   --provider-ref slack \
   flux-system
+
+  # Create an Alert for the events of several sources and resources
+  flux create alert \
+  --event-severity error \
+  --event-source GitRepository/flux-system \
+  --event-source Kustomization/flux-system \
+  --event-source HelmRelease/podinfo \
+  --provider-ref slack \
+  flux-system-errors
 `,
 	RunE: createAlertCmdRun,
 }
@@ -56,10 +69,21 @@ type alertFlags struct {
 
 var alertArgs alertFlags
 
+// supportedAlertEventSourceKinds are the kinds of objects an Alert
+// can receive events from.
+var supportedAlertEventSourceKinds = []string{
+	sourcev1.GitRepositoryKind,
+	sourcev1.BucketKind,
+	sourcev1.HelmRepositoryKind,
+	sourcev1.HelmChartKind,
+	kustomizev1.KustomizationKind,
+	helmv2.HelmReleaseKind,
+}
+
 func init() {
 	createAlertCmd.Flags().StringVar(&alertArgs.providerRef, "provider-ref", "", "reference to provider")
 	createAlertCmd.Flags().StringVar(&alertArgs.eventSeverity, "event-severity", "", "severity of events to send alerts for")
-	createAlertCmd.Flags().StringArrayVar(&alertArgs.eventSources, "event-source", []string{}, "sources that should generate alerts (<kind>/<name>)")
+	createAlertCmd.Flags().StringArrayVar(&alertArgs.eventSources, "event-source", []string{}, "sources that should generate alerts (<kind>/<name>), can be repeated")
 	createCmd.AddCommand(createAlertCmd)
 }
 
@@ -76,9 +100,13 @@ func createAlertCmdRun(cmd *cobra.Command, args []string) error {
 	eventSources := []notificationv1.CrossNamespaceObjectReference{}
 	for _, eventSource := range alertArgs.eventSources {
 		kind, name := utils.ParseObjectKindName(eventSource)
-		if kind == "" {
+		if kind == "" || name == "" {
 			return fmt.Errorf("invalid event source '%s', must be in format <kind>/<name>", eventSource)
 		}
+		kind, err := alertEventSourceKind(kind)
+		if err != nil {
+			return err
+		}
 
 		eventSources = append(eventSources, notificationv1.CrossNamespaceObjectReference{
 			Kind: kind,
@@ -142,6 +170,18 @@ func createAlertCmdRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// alertEventSourceKind returns the supported event source kind
+// matching the given kind case-insensitively.
+func alertEventSourceKind(kind string) (string, error) {
+	for _, k := range supportedAlertEventSourceKinds {
+		if strings.EqualFold(k, kind) {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported event source kind '%s', must be one of: %s",
+		kind, strings.Join(supportedAlertEventSourceKinds, ", "))
+}
+
 func upsertAlert(ctx context.Context, kubeClient client.Client,
 	alert *notificationv1.Alert) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
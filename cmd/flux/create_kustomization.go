@@ -22,11 +22,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -54,6 +56,13 @@ var createKsCmd = &cobra.Command{
     --health-check="DaemonSet/envoy.projectcontour" \
     --health-check-timeout=3m
 
+  # Create a Kustomization resource with garbage collection enabled, without a confirmation prompt
+  flux create kustomization podinfo \
+    --source=podinfo \
+    --path="./kustomize" \
+    --prune=true \
+    --yes
+
   # Create a Kustomization resource that depends on the previous one
   flux create kustomization webapp \
     --depends-on=contour \
@@ -68,6 +77,20 @@ var createKsCmd = &cobra.Command{
     --source=Bucket/secrets \
     --prune=true \
     --interval=5m
+
+  # Create a Kustomization resource that overrides the images of its manifests
+  flux create kustomization podinfo \
+    --source=podinfo \
+    --path="./kustomize" \
+    --images=ghcr.io/stefanprodan/podinfo=ghcr.io/stefanprodan/podinfo:5.0.3 \
+    --images=nginx=:1.19
+
+  # Create a Kustomization resource in a tenant namespace from a source shared in the flux-system namespace
+  flux create kustomization tenant-apps \
+    --namespace=tenant-a \
+    --source=GitRepository/shared \
+    --source-namespace=flux-system \
+    --path="./tenants/tenant-a"
 `,
 	RunE: createKsCmdRun,
 }
@@ -84,6 +107,9 @@ type kustomizationFlags struct {
 	decryptionProvider flags.DecryptionProvider
 	decryptionSecret   string
 	targetNamespace    string
+	sourceNamespace    string
+	images             []string
+	yes                bool
 }
 
 var kustomizationArgs = NewKustomizationFlags()
@@ -92,6 +118,7 @@ func init() {
 	createKsCmd.Flags().Var(&kustomizationArgs.source, "source", kustomizationArgs.source.Description())
 	createKsCmd.Flags().Var(&kustomizationArgs.path, "path", "path to the directory containing a kustomization.yaml file")
 	createKsCmd.Flags().BoolVar(&kustomizationArgs.prune, "prune", false, "enable garbage collection")
+	createKsCmd.Flags().BoolVar(&kustomizationArgs.yes, "yes", false, "skip the confirmation prompt when enabling garbage collection")
 	createKsCmd.Flags().StringArrayVar(&kustomizationArgs.healthCheck, "health-check", nil, "workload to be included in the health assessment, in the format '<kind>/<name>.<namespace>'")
 	createKsCmd.Flags().DurationVar(&kustomizationArgs.healthTimeout, "health-check-timeout", 2*time.Minute, "timeout of health checking operations")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.validation, "validation", "", "validate the manifests before applying them on the cluster, can be 'client' or 'server'")
@@ -100,6 +127,8 @@ func init() {
 	createKsCmd.Flags().Var(&kustomizationArgs.decryptionProvider, "decryption-provider", kustomizationArgs.decryptionProvider.Description())
 	createKsCmd.Flags().StringVar(&kustomizationArgs.decryptionSecret, "decryption-secret", "", "set the Kubernetes secret name that contains the OpenPGP private keys used for sops decryption")
 	createKsCmd.Flags().StringVar(&kustomizationArgs.targetNamespace, "target-namespace", "", "overrides the namespace of all Kustomization objects reconciled by this Kustomization")
+	createKsCmd.Flags().StringVar(&kustomizationArgs.sourceNamespace, "source-namespace", "", "the namespace of the source, defaults to the Kustomization namespace")
+	createKsCmd.Flags().StringArrayVar(&kustomizationArgs.images, "images", nil, "image override in the format '<name>=<new-name>:<new-tag>', the new name, tag or '@<digest>' can be omitted, can be repeated")
 	createCmd.AddCommand(createKsCmd)
 }
 
@@ -122,6 +151,15 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("path must begin with ./")
 	}
 
+	if ns := kustomizationArgs.sourceNamespace; ns != "" {
+		if err := validation.IsDNS1123Label(ns); len(err) > 0 {
+			return fmt.Errorf("invalid source namespace '%s': %v", ns, err)
+		}
+		if ns != rootArgs.namespace {
+			logger.Warningf("the source is in the %s namespace, kustomize-controller must be allowed to read sources across namespaces", ns)
+		}
+	}
+
 	if !createArgs.export {
 		logger.Generatef("generating Kustomization")
 	}
@@ -145,8 +183,9 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 			Path:  kustomizationArgs.path.String(),
 			Prune: kustomizationArgs.prune,
 			SourceRef: kustomizev1.CrossNamespaceSourceReference{
-				Kind: kustomizationArgs.source.Kind,
-				Name: kustomizationArgs.source.Name,
+				Kind:      kustomizationArgs.source.Kind,
+				Name:      kustomizationArgs.source.Name,
+				Namespace: kustomizationArgs.sourceNamespace,
 			},
 			Suspend:         false,
 			Validation:      kustomizationArgs.validation,
@@ -195,6 +234,14 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	for _, image := range kustomizationArgs.images {
+		override, err := parseKsImage(image)
+		if err != nil {
+			return err
+		}
+		kustomization.Spec.Images = append(kustomization.Spec.Images, override)
+	}
+
 	if kustomizationArgs.saName != "" {
 		kustomization.Spec.ServiceAccountName = kustomizationArgs.saName
 	}
@@ -213,6 +260,16 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 		return exportKs(kustomization)
 	}
 
+	if kustomizationArgs.prune && !kustomizationArgs.yes {
+		prompt := promptui.Prompt{
+			Label:     "Garbage collection allows Flux to delete resources removed from the source, are you sure you want to enable prune",
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("aborting")
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -238,6 +295,34 @@ func createKsCmdRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// parseKsImage parses an image override given as
+// '<name>=<new-name>:<new-tag>' or '<name>=<new-name>@<digest>',
+// where either the new name or the tag and digest can be omitted.
+func parseKsImage(image string) (kustomizev1.Image, error) {
+	invalid := fmt.Errorf("invalid image '%s', must be in the format '<name>=<new-name>:<new-tag>'", image)
+	parts := strings.SplitN(image, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return kustomizev1.Image{}, invalid
+	}
+	override := kustomizev1.Image{Name: parts[0]}
+	newName := parts[1]
+	if i := strings.Index(newName, "@"); i >= 0 {
+		override.Digest = newName[i+1:]
+		newName = newName[:i]
+		if override.Digest == "" {
+			return kustomizev1.Image{}, invalid
+		}
+	} else if i := strings.LastIndex(newName, ":"); i > strings.LastIndex(newName, "/") {
+		override.NewTag = newName[i+1:]
+		newName = newName[:i]
+		if override.NewTag == "" {
+			return kustomizev1.Image{}, invalid
+		}
+	}
+	override.NewName = newName
+	return override, nil
+}
+
 func upsertKustomization(ctx context.Context, kubeClient client.Client,
 	kustomization *kustomizev1.Kustomization) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
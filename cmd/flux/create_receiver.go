@@ -18,9 +18,12 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +49,21 @@ var createReceiverCmd = &cobra.Command{
 	--secret-ref webhook-token \
 	--resource GitRepository/webapp \
 	--resource HelmRepository/webapp
+
+  # Create a Receiver with a generated webhook token secret
+  flux create receiver github-receiver \
+	--type github \
+	--event push \
+	--generate-secret \
+	--resource GitRepository/webapp
+
+  # Replace the webhook token of the existing secret of a Receiver
+  flux create receiver github-receiver \
+	--type github \
+	--event push \
+	--generate-secret \
+	--rotate-token \
+	--resource GitRepository/webapp
 `,
 	RunE: createReceiverCmdRun,
 }
@@ -55,6 +73,9 @@ type receiverFlags struct {
 	secretRef    string
 	events       []string
 	resources    []string
+
+	generateSecret bool
+	rotateToken    bool
 }
 
 var receiverArgs receiverFlags
@@ -64,6 +85,10 @@ func init() {
 	createReceiverCmd.Flags().StringVar(&receiverArgs.secretRef, "secret-ref", "", "")
 	createReceiverCmd.Flags().StringArrayVar(&receiverArgs.events, "event", []string{}, "")
 	createReceiverCmd.Flags().StringArrayVar(&receiverArgs.resources, "resource", []string{}, "")
+	createReceiverCmd.Flags().BoolVar(&receiverArgs.generateSecret, "generate-secret", false,
+		"generate a random webhook token and store it in the Secret given by --secret-ref, or '<name>-token' if not set, an existing Secret keeps its token unless --rotate-token is set")
+	createReceiverCmd.Flags().BoolVar(&receiverArgs.rotateToken, "rotate-token", false,
+		"with --generate-secret, replace the token of an existing Secret with a new one")
 	createCmd.AddCommand(createReceiverCmd)
 }
 
@@ -77,8 +102,16 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Receiver type is required")
 	}
 
-	if receiverArgs.secretRef == "" {
-		return fmt.Errorf("secret ref is required")
+	if receiverArgs.rotateToken && !receiverArgs.generateSecret {
+		return fmt.Errorf("--rotate-token can only be used with --generate-secret")
+	}
+
+	secretName := receiverArgs.secretRef
+	if secretName == "" {
+		if !receiverArgs.generateSecret {
+			return fmt.Errorf("secret ref is required")
+		}
+		secretName = fmt.Sprintf("%s-token", name)
 	}
 
 	resources := []notificationv1.CrossNamespaceObjectReference{}
@@ -118,13 +151,36 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 			Events:    receiverArgs.events,
 			Resources: resources,
 			SecretRef: meta.LocalObjectReference{
-				Name: receiverArgs.secretRef,
+				Name: secretName,
 			},
 			Suspend: false,
 		},
 	}
 
+	var secret corev1.Secret
+	var token string
+	if receiverArgs.generateSecret {
+		if token, err = generateReceiverToken(); err != nil {
+			return err
+		}
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: rootArgs.namespace,
+				Labels:    sourceLabels,
+			},
+			StringData: map[string]string{
+				"token": token,
+			},
+		}
+	}
+
 	if createArgs.export {
+		if receiverArgs.generateSecret {
+			if err := exportSecret(secret); err != nil {
+				return err
+			}
+		}
 		return exportReceiver(receiver)
 	}
 
@@ -136,6 +192,24 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if receiverArgs.generateSecret {
+		var existing corev1.Secret
+		err := kubeClient.Get(ctx, types.NamespacedName{Namespace: rootArgs.namespace, Name: secretName}, &existing)
+		switch {
+		case err == nil && !receiverArgs.rotateToken:
+			logger.Successf("Secret %s already exists, keeping its webhook token", secretName)
+			token = ""
+		case err != nil && !errors.IsNotFound(err):
+			return err
+		default:
+			logger.Actionf("applying secret with webhook token")
+			if err := upsertSecret(ctx, kubeClient, secret); err != nil {
+				return err
+			}
+			logger.Successf("Secret %s created", secretName)
+		}
+	}
+
 	logger.Actionf("applying Receiver")
 	namespacedName, err := upsertReceiver(ctx, kubeClient, &receiver)
 	if err != nil {
@@ -150,9 +224,22 @@ func createReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("Receiver %s is ready", name)
 
 	logger.Successf("generated webhook URL %s", receiver.Status.URL)
+	if token != "" {
+		logger.Successf("generated webhook token %s", token)
+	}
 	return nil
 }
 
+// generateReceiverToken returns a random token to be used as the
+// webhook secret of a Receiver.
+func generateReceiverToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token failed: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 func upsertReceiver(ctx context.Context, kubeClient client.Client,
 	receiver *notificationv1.Receiver) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
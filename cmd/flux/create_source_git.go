@@ -23,6 +23,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -54,6 +55,11 @@ type SourceGitFlags struct {
 	GitECDSACurve     flags.ECDSACurve
 	GitSecretRef      string
 	GitImplementation flags.GitImplementation
+	GitIgnore         []string
+	GitIgnoreFile     string
+
+	GitVerifyProvider  string
+	GitVerifySecretRef string
 }
 
 var createSourceGitCmd = &cobra.Command{
@@ -96,6 +102,20 @@ For private Git repositories, the basic authentication credentials are stored in
     --url=https://github.com/stefanprodan/podinfo \
     --username=username \
     --password=password
+
+  # Create a source from a public Git repository, excluding the docs and CI files from the artifact
+  flux create source git podinfo \
+    --url=https://github.com/stefanprodan/podinfo \
+    --branch=master \
+    --ignore="/docs/" \
+    --ignore="/.github/"
+
+  # Create a source from a Git repository, verifying the OpenPGP signature of the commit HEAD points to
+  flux create source git podinfo \
+    --url=https://github.com/stefanprodan/podinfo \
+    --branch=master \
+    --verify-provider=pgp \
+    --verify-secret-ref=pgp-public-keys
 `,
 	RunE: createSourceGitCmdRun,
 }
@@ -114,10 +134,21 @@ func init() {
 	createSourceGitCmd.Flags().Var(&sourceArgs.GitECDSACurve, "ssh-ecdsa-curve", sourceArgs.GitECDSACurve.Description())
 	createSourceGitCmd.Flags().StringVarP(&sourceArgs.GitSecretRef, "secret-ref", "", "", "the name of an existing secret containing SSH or basic credentials")
 	createSourceGitCmd.Flags().Var(&sourceArgs.GitImplementation, "git-implementation", sourceArgs.GitImplementation.Description())
+	createSourceGitCmd.Flags().StringArrayVar(&sourceArgs.GitIgnore, "ignore", nil, "gitignore-style pattern of the paths to exclude from the artifact, can be repeated")
+	createSourceGitCmd.Flags().StringVar(&sourceArgs.GitIgnoreFile, "ignore-file", "", "path to a file with gitignore-style patterns of the paths to exclude from the artifact")
+
+	createSourceGitCmd.Flags().StringVar(&sourceArgs.GitVerifyProvider, "verify-provider", "",
+		fmt.Sprintf("verify the signature of the commit HEAD points to with the given provider, available options are: (%s)", strings.Join(supportedGitVerifyProviders, ", ")))
+	createSourceGitCmd.Flags().StringVar(&sourceArgs.GitVerifySecretRef, "verify-secret-ref", "",
+		"the name of an existing secret containing the public keys of the trusted Git authors, required by --verify-provider")
 
 	createSourceCmd.AddCommand(createSourceGitCmd)
 }
 
+// supportedGitVerifyProviders are the commit signature verification
+// providers of source-controller.
+var supportedGitVerifyProviders = []string{"pgp"}
+
 func NewSourceGitFlags() SourceGitFlags {
 	return SourceGitFlags{
 		GitKeyAlgorithm: "rsa",
@@ -152,6 +183,16 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	ignore, err := parseGitIgnore()
+	if err != nil {
+		return err
+	}
+
+	verification, err := parseGitVerification()
+	if err != nil {
+		return err
+	}
+
 	gitRepository := sourcev1.GitRepository{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -171,6 +212,12 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		gitRepository.Spec.GitImplementation = sourceArgs.GitImplementation.String()
 	}
 
+	if ignore != "" {
+		gitRepository.Spec.Ignore = &ignore
+	}
+
+	gitRepository.Spec.Verification = verification
+
 	if sourceArgs.GitSemver != "" {
 		gitRepository.Spec.Reference.SemVer = sourceArgs.GitSemver
 	} else if sourceArgs.GitTag != "" {
@@ -295,6 +342,59 @@ func createSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// parseGitVerification returns the commit verification given by
+// --verify-provider and --verify-secret-ref, or nil if none is given.
+// source-controller verifies the OpenPGP signature of the commit HEAD
+// points to.
+func parseGitVerification() (*sourcev1.GitRepositoryVerification, error) {
+	if sourceArgs.GitVerifyProvider == "" {
+		if sourceArgs.GitVerifySecretRef != "" {
+			return nil, fmt.Errorf("--verify-secret-ref requires --verify-provider")
+		}
+		return nil, nil
+	}
+	if !utils.ContainsItemString(supportedGitVerifyProviders, sourceArgs.GitVerifyProvider) {
+		return nil, fmt.Errorf("unsupported verify provider '%s', must be one of: %s",
+			sourceArgs.GitVerifyProvider, strings.Join(supportedGitVerifyProviders, ", "))
+	}
+	if sourceArgs.GitVerifySecretRef == "" {
+		return nil, fmt.Errorf("--verify-secret-ref is required with --verify-provider")
+	}
+	return &sourcev1.GitRepositoryVerification{
+		Mode: "head",
+		SecretRef: meta.LocalObjectReference{
+			Name: sourceArgs.GitVerifySecretRef,
+		},
+	}, nil
+}
+
+// parseGitIgnore returns the ignore rules read from --ignore-file,
+// followed by the patterns given with --ignore.
+func parseGitIgnore() (string, error) {
+	var rules []string
+	if sourceArgs.GitIgnoreFile != "" {
+		data, err := ioutil.ReadFile(sourceArgs.GitIgnoreFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read ignore file: %w", err)
+		}
+		content := strings.TrimSpace(string(data))
+		if content == "" {
+			return "", fmt.Errorf("ignore file '%s' is empty", sourceArgs.GitIgnoreFile)
+		}
+		rules = append(rules, content)
+	}
+	for _, pattern := range sourceArgs.GitIgnore {
+		if strings.TrimSpace(pattern) == "" {
+			return "", fmt.Errorf("ignore patterns can't be empty")
+		}
+		rules = append(rules, pattern)
+	}
+	if len(rules) == 0 {
+		return "", nil
+	}
+	return strings.Join(rules, "\n") + "\n", nil
+}
+
 func upsertGitRepository(ctx context.Context, kubeClient client.Client,
 	gitRepository *sourcev1.GitRepository) (types.NamespacedName, error) {
 	namespacedName := types.NamespacedName{
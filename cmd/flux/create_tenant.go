@@ -30,6 +30,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 )
@@ -50,6 +51,11 @@ reconcilers scope to the tenant namespaces.`,
     --with-namespace=frontend \
     --with-namespace=backend \
 	--export > dev-team.yaml
+
+  # Create a tenant and print a token of its service account for CI impersonation
+  flux create tenant dev-team \
+    --with-namespace=frontend \
+    --with-service-account-token
 `,
 	RunE: createTenantCmdRun,
 }
@@ -59,8 +65,9 @@ const (
 )
 
 type tenantFlags struct {
-	namespaces  []string
-	clusterRole string
+	namespaces   []string
+	clusterRole  string
+	accountToken bool
 }
 
 var tenantArgs tenantFlags
@@ -68,6 +75,8 @@ var tenantArgs tenantFlags
 func init() {
 	createTenantCmd.Flags().StringSliceVar(&tenantArgs.namespaces, "with-namespace", nil, "namespace belonging to this tenant")
 	createTenantCmd.Flags().StringVar(&tenantArgs.clusterRole, "cluster-role", "cluster-admin", "cluster role of the tenant role binding")
+	createTenantCmd.Flags().BoolVar(&tenantArgs.accountToken, "with-service-account-token", false,
+		"generate a long-lived token secret bound to the tenant service account and print the token")
 	createCmd.AddCommand(createTenantCmd)
 }
 
@@ -91,6 +100,7 @@ func createTenantCmdRun(cmd *cobra.Command, args []string) error {
 	var namespaces []corev1.Namespace
 	var accounts []corev1.ServiceAccount
 	var roleBindings []rbacv1.RoleBinding
+	var tokens []corev1.Secret
 
 	for _, ns := range tenantArgs.namespaces {
 		if err := validation.IsQualifiedName(ns); len(err) > 0 {
@@ -147,6 +157,24 @@ func createTenantCmdRun(cmd *cobra.Command, args []string) error {
 			},
 		}
 		roleBindings = append(roleBindings, roleBinding)
+
+		if tenantArgs.accountToken {
+			tokens = append(tokens, corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-token", tenant),
+					Namespace: ns,
+					Labels:    objLabels,
+					Annotations: map[string]string{
+						corev1.ServiceAccountNameKey: tenant,
+					},
+				},
+				Type: corev1.SecretTypeServiceAccountToken,
+			})
+		}
+	}
+
+	if tenantArgs.accountToken {
+		logger.Warningf("service account tokens don't expire, store them securely and delete the %s-token secrets to revoke them", tenant)
 	}
 
 	if createArgs.export {
@@ -154,6 +182,11 @@ func createTenantCmdRun(cmd *cobra.Command, args []string) error {
 			if err := exportTenant(namespaces[i], accounts[i], roleBindings[i]); err != nil {
 				return err
 			}
+			if tenantArgs.accountToken {
+				if err := exportSecret(tokens[i]); err != nil {
+					return err
+				}
+			}
 		}
 		return nil
 	}
@@ -181,12 +214,50 @@ func createTenantCmdRun(cmd *cobra.Command, args []string) error {
 		if err := upsertRoleBinding(ctx, kubeClient, roleBindings[i]); err != nil {
 			return err
 		}
+
+		if tenantArgs.accountToken {
+			logger.Actionf("applying service account token %s", tokens[i].Name)
+			if err := upsertSecret(ctx, kubeClient, tokens[i]); err != nil {
+				return err
+			}
+			logger.Waitingf("waiting for the token to be generated")
+			token, err := waitForServiceAccountToken(ctx, kubeClient, tokens[i])
+			if err != nil {
+				return err
+			}
+			logger.Successf("generated service account token for %s/%s %s", namespaces[i].Name, tenant, token)
+		}
 	}
 
 	logger.Successf("tenant setup completed")
 	return nil
 }
 
+// waitForServiceAccountToken returns the token populated by the token
+// controller in the service account token secret.
+func waitForServiceAccountToken(ctx context.Context, kubeClient client.Client, secret corev1.Secret) (string, error) {
+	namespacedName := types.NamespacedName{
+		Namespace: secret.GetNamespace(),
+		Name:      secret.GetName(),
+	}
+
+	var token string
+	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout, func() (bool, error) {
+		var existing corev1.Secret
+		if err := kubeClient.Get(ctx, namespacedName, &existing); err != nil {
+			return false, err
+		}
+		if data, ok := existing.Data[corev1.ServiceAccountTokenKey]; ok && len(data) > 0 {
+			token = string(data)
+			return true, nil
+		}
+		return false, nil
+	}); err != nil {
+		return "", fmt.Errorf("token of secret %s not generated: %w", namespacedName, err)
+	}
+	return token, nil
+}
+
 func upsertNamespace(ctx context.Context, kubeClient client.Client, namespace corev1.Namespace) error {
 	namespacedName := types.NamespacedName{
 		Namespace: namespace.GetNamespace(),
@@ -19,10 +19,23 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
@@ -33,20 +46,138 @@ var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export resources in YAML format",
 	Long:  "The export sub-commands export resources in YAML format.",
+	Example: `  # Export the Kustomizations modified in the last 24 hours, e.g. for an incremental backup
+  flux export kustomization --all --since 24h > kustomizations.yaml
+
+  # Export the GitRepositories modified since a point in time
+  flux export source git --all --since 2021-03-01T00:00:00Z > sources.yaml
+
+  # Export the Kustomizations together with their CRD, to apply them on a cluster without Flux
+  flux export kustomization --all --include-crds > kustomizations.yaml
+`,
+	PersistentPreRunE:  validateExportFlags,
+	PersistentPostRunE: printPendingExports,
 }
 
 type exportFlags struct {
-	all bool
+	all           bool
+	format        string
+	pruneDefaults bool
+
+	namespaceScopedOnly bool
+	clusterScopedOnly   bool
+
+	since     string
+	sinceTime time.Time
+
+	includeCRDs bool
 }
 
 var exportArgs exportFlags
 
+var supportedExportFormats = []string{"yaml", "hcl"}
+
 func init() {
 	exportCmd.PersistentFlags().BoolVar(&exportArgs.all, "all", false, "select all resources")
+	exportCmd.PersistentFlags().StringVar(&exportArgs.format, "format", "yaml",
+		fmt.Sprintf("the format of the exported resources, available options are: (%s), "+
+			"hcl is a best-effort conversion to Terraform kubernetes_manifest resources", strings.Join(supportedExportFormats, ", ")))
+
+	exportCmd.PersistentFlags().BoolVar(&exportArgs.pruneDefaults, "prune-defaults", false,
+		"remove the spec fields equal to the defaults of the CRD schema, unless they were set in the last applied configuration")
+	exportCmd.PersistentFlags().BoolVar(&exportArgs.namespaceScopedOnly, "namespace-scoped-only", false,
+		"export only the namespace-scoped resources")
+	exportCmd.PersistentFlags().BoolVar(&exportArgs.clusterScopedOnly, "cluster-scoped-only", false,
+		"export only the cluster-scoped resources")
+	exportCmd.PersistentFlags().BoolVar(&exportArgs.includeCRDs, "include-crds", false,
+		"print the CRD of each exported kind, as found on the cluster, ahead of its first resource")
+	exportCmd.PersistentFlags().StringVar(&exportArgs.since, "since", "",
+		"with --all, export only the resources modified after the given RFC3339 timestamp or within the given duration, e.g. 24h")
 
 	rootCmd.AddCommand(exportCmd)
 }
 
+// validateExportFlags parses the --since value, which is either an
+// RFC3339 timestamp or a duration before now.
+func validateExportFlags(cmd *cobra.Command, args []string) error {
+	if exportArgs.namespaceScopedOnly && exportArgs.clusterScopedOnly {
+		return fmt.Errorf("--namespace-scoped-only and --cluster-scoped-only can't be used together")
+	}
+	if exportArgs.since == "" {
+		return nil
+	}
+	if !exportArgs.all {
+		return fmt.Errorf("--since requires --all")
+	}
+	if d, err := time.ParseDuration(exportArgs.since); err == nil {
+		exportArgs.sinceTime = time.Now().Add(-d)
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, exportArgs.since)
+	if err != nil {
+		return fmt.Errorf("invalid --since '%s', must be an RFC3339 timestamp or a duration", exportArgs.since)
+	}
+	exportArgs.sinceTime = t
+	return nil
+}
+
+// filterModifiedSince removes from the list the objects that haven't
+// been modified after --since. The modification time is the latest
+// time of the managed fields that don't only touch the status, or the
+// creation time if there are none.
+func filterModifiedSince(list client.ObjectList) error {
+	if exportArgs.since == "" {
+		return nil
+	}
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var modified []runtime.Object
+	for _, item := range items {
+		accessor, err := apimeta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		if lastModified(accessor).After(exportArgs.sinceTime) {
+			modified = append(modified, item)
+		}
+	}
+	return apimeta.SetList(list, modified)
+}
+
+// lastModified returns the time the spec or metadata of the object was
+// last modified. The status updates of the controllers happen at every
+// reconciliation, they are not taken into account.
+func lastModified(obj metav1.Object) time.Time {
+	t := obj.GetCreationTimestamp().Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(t) && !updatesOnlyStatus(entry) {
+			t = entry.Time.Time
+		}
+	}
+	return t
+}
+
+// updatesOnlyStatus returns true if the managed fields entry only owns
+// fields of the status. The managed fields of the Kubernetes API this
+// CLI is built against don't record the subresource of an entry.
+func updatesOnlyStatus(entry metav1.ManagedFieldsEntry) bool {
+	if entry.FieldsV1 == nil {
+		return false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil || len(fields) == 0 {
+		return false
+	}
+	for key := range fields {
+		if key != "f:status" {
+			return false
+		}
+	}
+	return true
+}
+
 // exportable represents a type that you can fetch from the Kubernetes
 // API, then tidy up for serialising.
 type exportable interface {
@@ -84,6 +215,9 @@ func (export exportCommand) run(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if err := filterModifiedSince(export.list.asClientList()); err != nil {
+			return err
+		}
 
 		if export.list.len() == 0 {
 			logger.Failuref("no objects found in %s namespace", rootArgs.namespace)
@@ -111,15 +245,449 @@ func (export exportCommand) run(cmd *cobra.Command, args []string) error {
 }
 
 func printExport(export interface{}) error {
-	data, err := yaml.Marshal(export)
+	if exportArgs.namespaceScopedOnly || exportArgs.clusterScopedOnly {
+		matches, err := exportScopeMatches(export)
+		if err != nil {
+			return err
+		}
+		if !matches {
+			return nil
+		}
+	}
+
+	var gvk schema.GroupVersionKind
+	if exportArgs.includeCRDs {
+		var err error
+		if gvk, err = exportGroupVersionKind(export); err != nil {
+			return err
+		}
+	}
+
+	if exportArgs.pruneDefaults {
+		pruned, err := pruneExportDefaults(export)
+		if err != nil {
+			return err
+		}
+		export = pruned
+	}
+
+	if exportArgs.includeCRDs {
+		// the objects are printed after all the CRDs
+		pendingExports = append(pendingExports, pendingExport{gvk: gvk, export: export})
+		return nil
+	}
+	return printExportFormat(export)
+}
+
+// pendingExport is an object held back by --include-crds until the
+// CRDs of all the exported kinds are printed.
+type pendingExport struct {
+	gvk    schema.GroupVersionKind
+	export interface{}
+}
+
+var pendingExports []pendingExport
+
+// printPendingExports prints the CRDs of the kinds of the objects held
+// back by --include-crds, then the objects, so that applying the
+// output creates every CRD ahead of the custom resources.
+func printPendingExports(cmd *cobra.Command, args []string) error {
+	for _, pending := range pendingExports {
+		if err := printExportCRD(pending.gvk); err != nil {
+			return err
+		}
+	}
+	for _, pending := range pendingExports {
+		if err := printExportFormat(pending.export); err != nil {
+			return err
+		}
+	}
+	pendingExports = nil
+	return nil
+}
+
+// exportGroupVersionKind returns the kind of the exported object.
+func exportGroupVersionKind(export interface{}) (schema.GroupVersionKind, error) {
+	data, err := json.Marshal(export)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(data, &typeMeta); err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return typeMeta.GroupVersionKind(), nil
+}
+
+// printExportFormat prints the object in the format given by --format.
+func printExportFormat(export interface{}) error {
+	switch exportArgs.format {
+	case "yaml":
+		data, err := yaml.Marshal(export)
+		if err != nil {
+			return err
+		}
+		fmt.Println("---")
+		fmt.Println(resourceToString(data))
+		return nil
+	case "hcl":
+		return printExportHCL(export)
+	}
+	return fmt.Errorf("unsupported export format '%s', must be one of: %s",
+		exportArgs.format, strings.Join(supportedExportFormats, ", "))
+}
+
+// exportedCRDs records the kinds whose CRD has already been printed.
+var exportedCRDs = map[schema.GroupVersionKind]bool{}
+
+// printExportCRD prints the CRD of the kind, without its status and
+// server-set metadata, unless it has been printed already. Kinds that
+// aren't defined by a CRD are skipped.
+func printExportCRD(gvk schema.GroupVersionKind) error {
+	if gvk.Group == "" || exportedCRDs[gvk] {
+		return nil
+	}
+	exportedCRDs[gvk] = true
+
+	crd, err := exportCRD(gvk)
+	if err != nil || crd == nil {
+		return err
+	}
+	annotations := map[string]string{}
+	for key, value := range crd.Annotations {
+		if key != lastAppliedAnnotation {
+			annotations[key] = value
+		}
+	}
+	data, err := json.Marshal(apiextensionsv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       "CustomResourceDefinition",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        crd.Name,
+			Labels:      crd.Labels,
+			Annotations: annotations,
+		},
+		Spec: crd.Spec,
+	})
+	if err != nil {
+		return err
+	}
+	// the status of a CRD is not a pointer, drop it instead of printing
+	// its empty accepted names
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	delete(obj, "status")
+	return printExportFormat(obj)
+}
+
+// exportNamespaced caches whether the exported kinds are namespaced.
+var exportNamespaced = map[schema.GroupVersionKind]bool{}
+
+// exportScopeMatches returns true if the scope of the object's kind,
+// as found with the discovery API, is the one selected by the
+// --namespace-scoped-only or --cluster-scoped-only flag.
+func exportScopeMatches(export interface{}) (bool, error) {
+	gvk, err := exportGroupVersionKind(export)
+	if err != nil {
+		return false, err
+	}
+
+	namespaced, ok := exportNamespaced[gvk]
+	if !ok {
+		cfg, err := utils.KubeConfig(rootArgs.kubeconfig, rootArgs.kubecontext)
+		if err != nil {
+			return false, err
+		}
+		clientSet, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			return false, err
+		}
+		resources, err := clientSet.Discovery().ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+		if err != nil {
+			return false, err
+		}
+		found := false
+		for _, resource := range resources.APIResources {
+			if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+				namespaced, found = resource.Namespaced, true
+				break
+			}
+		}
+		if !found {
+			return false, fmt.Errorf("the scope of %s can't be determined, %s is not served by the cluster", gvk.Kind, gvk.GroupVersion().String())
+		}
+		exportNamespaced[gvk] = namespaced
+	}
+
+	if exportArgs.namespaceScopedOnly {
+		return namespaced, nil
+	}
+	return !namespaced, nil
+}
+
+// lastAppliedAnnotation holds the configuration last applied with
+// kubectl, used to tell which fields were explicitly set.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// exportSchemas caches the OpenAPI schema of the exported kinds.
+var exportSchemas = map[schema.GroupVersionKind]*apiextensionsv1.JSONSchemaProps{}
+
+// exportCRDs caches the CRDs of the exported kinds.
+var exportCRDs = map[schema.GroupKind]*apiextensionsv1.CustomResourceDefinition{}
+
+// pruneExportDefaults returns the object as a map, without the spec
+// fields that are equal to their default in the CRD schema. Fields
+// found in the last applied configuration are kept, as they have been
+// set explicitly. Objects without a CRD are returned unchanged.
+func pruneExportDefaults(export interface{}) (interface{}, error) {
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	if gv.Group == "" {
+		return export, nil
+	}
+	crdSchema, err := exportSchema(gv.WithKind(kind))
+	if err != nil {
+		return nil, err
+	}
+	if crdSchema == nil {
+		return export, nil
+	}
+	specSchema, ok := crdSchema.Properties["spec"]
+	if !ok {
+		return export, nil
+	}
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return export, nil
+	}
+
+	var lastApplied map[string]interface{}
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			if config, ok := annotations[lastAppliedAnnotation].(string); ok {
+				var applied map[string]interface{}
+				if err := json.Unmarshal([]byte(config), &applied); err == nil {
+					lastApplied, _ = applied["spec"].(map[string]interface{})
+				}
+			}
+		}
+	}
+
+	pruneDefaults(spec, &specSchema, lastApplied)
+	return obj, nil
+}
+
+// exportSchema returns the OpenAPI schema of the given kind, from the
+// CRD installed on the cluster, or nil if there is no such CRD.
+func exportSchema(gvk schema.GroupVersionKind) (*apiextensionsv1.JSONSchemaProps, error) {
+	if s, ok := exportSchemas[gvk]; ok {
+		return s, nil
+	}
+
+	crd, err := exportCRD(gvk)
 	if err != nil {
+		return nil, err
+	}
+	if crd != nil {
+		for _, version := range crd.Spec.Versions {
+			if version.Name == gvk.Version && version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+				exportSchemas[gvk] = version.Schema.OpenAPIV3Schema
+				return version.Schema.OpenAPIV3Schema, nil
+			}
+		}
+	}
+	exportSchemas[gvk] = nil
+	return nil, nil
+}
+
+// exportCRD returns the CRD installed on the cluster that defines the
+// given kind, or nil if there is no such CRD.
+func exportCRD(gvk schema.GroupVersionKind) (*apiextensionsv1.CustomResourceDefinition, error) {
+	gk := gvk.GroupKind()
+	if crd, ok := exportCRDs[gk]; ok {
+		return crd, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return nil, err
+	}
+	var list apiextensionsv1.CustomResourceDefinitionList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return nil, err
+	}
+	for i, crd := range list.Items {
+		if crd.Spec.Group == gk.Group && crd.Spec.Names.Kind == gk.Kind {
+			exportCRDs[gk] = &list.Items[i]
+			return &list.Items[i], nil
+		}
+	}
+	exportCRDs[gk] = nil
+	return nil, nil
+}
+
+// pruneDefaults removes the fields of obj that are equal to their
+// default in the schema and are not set in the applied object.
+func pruneDefaults(obj map[string]interface{}, s *apiextensionsv1.JSONSchemaProps, applied map[string]interface{}) {
+	for key, value := range obj {
+		propSchema, ok := s.Properties[key]
+		if !ok {
+			continue
+		}
+		_, explicit := applied[key]
+
+		if propSchema.Default != nil && !explicit {
+			var def interface{}
+			if err := json.Unmarshal(propSchema.Default.Raw, &def); err == nil && reflect.DeepEqual(value, def) {
+				delete(obj, key)
+				continue
+			}
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			appliedValue, _ := applied[key].(map[string]interface{})
+			pruneDefaults(v, &propSchema, appliedValue)
+		case []interface{}:
+			if propSchema.Items == nil || propSchema.Items.Schema == nil {
+				continue
+			}
+			appliedItems, _ := applied[key].([]interface{})
+			for i, item := range v {
+				if itemObj, ok := item.(map[string]interface{}); ok {
+					var appliedItem map[string]interface{}
+					if i < len(appliedItems) {
+						appliedItem, _ = appliedItems[i].(map[string]interface{})
+					}
+					pruneDefaults(itemObj, propSchema.Items.Schema, appliedItem)
+				}
+			}
+		}
+	}
+}
+
+var hclNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// printExportHCL prints the object as a Terraform kubernetes_manifest
+// resource. This is a best-effort conversion, the output is meant to
+// be reviewed before use.
+func printExportHCL(export interface{}) error {
+	data, err := json.Marshal(export)
+	if err != nil {
+		return err
+	}
+	var manifest map[string]interface{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
 		return err
 	}
-	fmt.Println("---")
-	fmt.Println(resourceToString(data))
+	if status, ok := manifest["status"].(map[string]interface{}); ok && len(status) == 0 {
+		delete(manifest, "status")
+	}
+	var name []string
+	if kind, ok := manifest["kind"].(string); ok {
+		name = append(name, strings.ToLower(kind))
+	}
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		if metadata["creationTimestamp"] == nil {
+			delete(metadata, "creationTimestamp")
+		}
+		for _, key := range []string{"namespace", "name"} {
+			if value, ok := metadata[key].(string); ok {
+				name = append(name, value)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# best-effort conversion by flux export, review before use\n")
+	fmt.Fprintf(&b, "resource \"kubernetes_manifest\" \"%s\" {\n", hclNameReplacer.ReplaceAllString(strings.Join(name, "_"), "_"))
+	b.WriteString("  manifest = ")
+	writeHCLValue(&b, manifest, 1)
+	b.WriteString("\n}\n")
+	fmt.Println(b.String())
 	return nil
 }
 
+var hclIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+func writeHCLValue(b *strings.Builder, value interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("{\n")
+		for _, key := range keys {
+			b.WriteString(indent + "  ")
+			if hclIdentifier.MatchString(key) {
+				b.WriteString(key)
+			} else {
+				b.WriteString(hclString(key))
+			}
+			b.WriteString(" = ")
+			writeHCLValue(b, v[key], depth+1)
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, item := range v {
+			b.WriteString(indent + "  ")
+			writeHCLValue(b, item, depth+1)
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+	case string:
+		b.WriteString(hclString(v))
+	case float64:
+		b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case nil:
+		b.WriteString("null")
+	default:
+		b.WriteString(hclString(fmt.Sprint(v)))
+	}
+}
+
+// hclString quotes a string, escaping the HCL template sequences.
+func hclString(s string) string {
+	s = strconv.Quote(s)
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
 func resourceToString(data []byte) string {
 	data = bytes.Replace(data, []byte("  creationTimestamp: null\n"), []byte(""), 1)
 	data = bytes.Replace(data, []byte("status: {}\n"), []byte(""), 1)
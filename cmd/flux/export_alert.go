@@ -24,7 +24,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
@@ -66,6 +65,9 @@ func exportAlertCmdRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if err := filterModifiedSince(&list); err != nil {
+			return err
+		}
 
 		if len(list.Items) == 0 {
 			logger.Failuref("no alerts found in %s namespace", rootArgs.namespace)
@@ -109,12 +111,5 @@ func exportAlert(alert notificationv1.Alert) error {
 		Spec: alert.Spec,
 	}
 
-	data, err := yaml.Marshal(export)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("---")
-	fmt.Println(resourceToString(data))
-	return nil
+	return printExport(export)
 }
@@ -24,7 +24,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
@@ -67,6 +66,9 @@ func exportHelmReleaseCmdRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if err := filterModifiedSince(&list); err != nil {
+			return err
+		}
 
 		if len(list.Items) == 0 {
 			logger.Failuref("no helmrelease found in %s namespace", rootArgs.namespace)
@@ -110,12 +112,5 @@ func exportHelmRelease(helmRelease helmv2.HelmRelease) error {
 		Spec: helmRelease.Spec,
 	}
 
-	data, err := yaml.Marshal(export)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("---")
-	fmt.Println(resourceToString(data))
-	return nil
+	return printExport(export)
 }
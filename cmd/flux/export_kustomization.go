@@ -24,10 +24,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 )
 
 var exportKsCmd = &cobra.Command{
@@ -40,11 +40,29 @@ var exportKsCmd = &cobra.Command{
 
   # Export a Kustomization
   flux export kustomization my-app > kustomization.yaml
+
+  # Export a Kustomization together with its source and the source credentials
+  flux export kustomization my-app --with-source --with-credentials > my-app.yaml
+
+  # Export a Kustomization as a Terraform kubernetes_manifest resource
+  flux export kustomization my-app --format hcl > my-app.tf
+
+  # Export a Kustomization without the fields set to their default values
+  flux export kustomization my-app --prune-defaults > kustomization.yaml
 `,
 	RunE: exportKsCmdRun,
 }
 
+type exportKsFlags struct {
+	withSource      bool
+	withCredentials bool
+}
+
+var exportKsArgs exportKsFlags
+
 func init() {
+	exportKsCmd.Flags().BoolVar(&exportKsArgs.withSource, "with-source", false, "include the source referenced by the Kustomization")
+	exportKsCmd.Flags().BoolVar(&exportKsArgs.withCredentials, "with-credentials", false, "include the credential secrets of the source, requires --with-source")
 	exportCmd.AddCommand(exportKsCmd)
 }
 
@@ -53,6 +71,10 @@ func exportKsCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("kustomization name is required")
 	}
 
+	if exportKsArgs.withCredentials && !exportKsArgs.withSource {
+		return fmt.Errorf("--with-credentials requires --with-source")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -67,13 +89,22 @@ func exportKsCmdRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if err := filterModifiedSince(&list); err != nil {
+			return err
+		}
 
 		if len(list.Items) == 0 {
 			logger.Failuref("no kustomizations found in %s namespace", rootArgs.namespace)
 			return nil
 		}
 
+		exportedSources := map[string]bool{}
 		for _, kustomization := range list.Items {
+			if exportKsArgs.withSource {
+				if err := exportKsSource(ctx, kubeClient, kustomization, exportedSources); err != nil {
+					return err
+				}
+			}
 			if err := exportKs(kustomization); err != nil {
 				return err
 			}
@@ -89,6 +120,11 @@ func exportKsCmdRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if exportKsArgs.withSource {
+			if err := exportKsSource(ctx, kubeClient, kustomization, map[string]bool{}); err != nil {
+				return err
+			}
+		}
 		return exportKs(kustomization)
 	}
 	return nil
@@ -110,12 +146,53 @@ func exportKs(kustomization kustomizev1.Kustomization) error {
 		Spec: kustomization.Spec,
 	}
 
-	data, err := yaml.Marshal(export)
-	if err != nil {
-		return err
+	return printExport(export)
+}
+
+// exportKsSource exports the source referenced by a Kustomization,
+// preceded by the source credentials when requested, so the output
+// can be applied in order on a fresh cluster. Sources already present
+// in exported are skipped.
+func exportKsSource(ctx context.Context, kubeClient client.Client, kustomization kustomizev1.Kustomization, exported map[string]bool) error {
+	sourceRef := kustomization.Spec.SourceRef
+	namespacedName := types.NamespacedName{
+		Namespace: kustomization.Namespace,
+		Name:      sourceRef.Name,
+	}
+	if sourceRef.Namespace != "" {
+		namespacedName.Namespace = sourceRef.Namespace
 	}
 
-	fmt.Println("---")
-	fmt.Println(resourceToString(data))
-	return nil
+	key := fmt.Sprintf("%s/%s", sourceRef.Kind, namespacedName)
+	if exported[key] {
+		return nil
+	}
+	exported[key] = true
+
+	switch sourceRef.Kind {
+	case sourcev1.GitRepositoryKind:
+		var repository sourcev1.GitRepository
+		if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+			return err
+		}
+		if exportKsArgs.withCredentials {
+			if err := exportGitCredentials(ctx, kubeClient, repository); err != nil {
+				return err
+			}
+		}
+		return exportGit(repository)
+	case sourcev1.BucketKind:
+		var bucket sourcev1.Bucket
+		if err := kubeClient.Get(ctx, namespacedName, &bucket); err != nil {
+			return err
+		}
+		if exportKsArgs.withCredentials {
+			if err := exportBucketCredentials(ctx, kubeClient, bucket); err != nil {
+				return err
+			}
+		}
+		return exportBucket(bucket)
+	default:
+		return fmt.Errorf("source kind '%s' is not supported", sourceRef.Kind)
+	}
 }
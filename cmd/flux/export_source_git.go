@@ -25,7 +25,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/yaml"
 
 	"github.com/fluxcd/flux2/internal/utils"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
@@ -67,6 +66,9 @@ func exportSourceGitCmdRun(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if err := filterModifiedSince(&list); err != nil {
+			return err
+		}
 
 		if len(list.Items) == 0 {
 			logger.Failuref("no source found in %s namespace", rootArgs.namespace)
@@ -120,14 +122,7 @@ func exportGit(source sourcev1.GitRepository) error {
 		Spec: source.Spec,
 	}
 
-	data, err := yaml.Marshal(export)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("---")
-	fmt.Println(resourceToString(data))
-	return nil
+	return printExport(export)
 }
 
 func exportGitCredentials(ctx context.Context, kubeClient client.Client, source sourcev1.GitRepository) error {
@@ -155,13 +150,9 @@ func exportGitCredentials(ctx context.Context, kubeClient client.Client, source
 			Type: cred.Type,
 		}
 
-		data, err := yaml.Marshal(exported)
-		if err != nil {
+		if err := printExport(exported); err != nil {
 			return err
 		}
-
-		fmt.Println("---")
-		fmt.Println(resourceToString(data))
 	}
 	return nil
 }
@@ -0,0 +1,276 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPrintExportScopeWithCRDs(t *testing.T) {
+	namespacedGVK := schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: "v1beta1", Kind: "GitRepository"}
+	clusterGVK := schema.GroupVersionKind{Group: "toolkit.fluxcd.io", Version: "v1", Kind: "Cluster"}
+	exportNamespaced[namespacedGVK] = true
+	exportNamespaced[clusterGVK] = false
+	defaultArgs := exportArgs
+	defer func() {
+		delete(exportNamespaced, namespacedGVK)
+		delete(exportNamespaced, clusterGVK)
+		exportArgs = defaultArgs
+		pendingExports = nil
+	}()
+
+	tests := []struct {
+		name                string
+		namespaceScopedOnly bool
+		clusterScopedOnly   bool
+		expect              []schema.GroupVersionKind
+	}{
+		{"namespace scoped only", true, false, []schema.GroupVersionKind{namespacedGVK}},
+		{"cluster scoped only", false, true, []schema.GroupVersionKind{clusterGVK}},
+		{"all scopes", false, false, []schema.GroupVersionKind{namespacedGVK, clusterGVK}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exportArgs = defaultArgs
+			exportArgs.namespaceScopedOnly = tt.namespaceScopedOnly
+			exportArgs.clusterScopedOnly = tt.clusterScopedOnly
+			exportArgs.includeCRDs = true
+			pendingExports = nil
+
+			for _, gvk := range []schema.GroupVersionKind{namespacedGVK, clusterGVK} {
+				object := map[string]interface{}{
+					"apiVersion": gvk.GroupVersion().String(),
+					"kind":       gvk.Kind,
+				}
+				if err := printExport(object); err != nil {
+					t.Fatalf("printExport() error = %v", err)
+				}
+			}
+
+			if len(pendingExports) != len(tt.expect) {
+				t.Fatalf("pending exports = %d, expect %d", len(pendingExports), len(tt.expect))
+			}
+			for i, gvk := range tt.expect {
+				if pendingExports[i].gvk != gvk {
+					t.Errorf("pending export %d = %s, expect %s", i, pendingExports[i].gvk, gvk)
+				}
+			}
+		})
+	}
+}
+
+func testSpecSchema() apiextensionsv1.JSONSchemaProps {
+	defaultValue := func(raw string) *apiextensionsv1.JSON {
+		return &apiextensionsv1.JSON{Raw: []byte(raw)}
+	}
+	return apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"interval": {Type: "string"},
+			"suspend":  {Type: "boolean", Default: defaultValue("false")},
+			"prune":    {Type: "boolean", Default: defaultValue("true")},
+			"retry": {
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"count": {Type: "integer", Default: defaultValue("3")},
+				},
+			},
+			"targets": {
+				Type: "array",
+				Items: &apiextensionsv1.JSONSchemaPropsOrArray{
+					Schema: &apiextensionsv1.JSONSchemaProps{
+						Type: "object",
+						Properties: map[string]apiextensionsv1.JSONSchemaProps{
+							"weight": {Type: "integer", Default: defaultValue("1")},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPruneDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     map[string]interface{}
+		applied map[string]interface{}
+		expect  map[string]interface{}
+	}{
+		{
+			name: "defaults removed",
+			obj: map[string]interface{}{
+				"interval": "1m",
+				"suspend":  false,
+				"prune":    false,
+				"retry":    map[string]interface{}{"count": float64(3)},
+				"targets": []interface{}{
+					map[string]interface{}{"name": "a", "weight": float64(1)},
+					map[string]interface{}{"name": "b", "weight": float64(2)},
+				},
+			},
+			expect: map[string]interface{}{
+				"interval": "1m",
+				"prune":    false,
+				"retry":    map[string]interface{}{},
+				"targets": []interface{}{
+					map[string]interface{}{"name": "a"},
+					map[string]interface{}{"name": "b", "weight": float64(2)},
+				},
+			},
+		},
+		{
+			name: "explicit defaults kept",
+			obj: map[string]interface{}{
+				"suspend": false,
+				"retry":   map[string]interface{}{"count": float64(3)},
+				"targets": []interface{}{
+					map[string]interface{}{"name": "a", "weight": float64(1)},
+				},
+			},
+			applied: map[string]interface{}{
+				"suspend": false,
+				"retry":   map[string]interface{}{"count": 3},
+				"targets": []interface{}{
+					map[string]interface{}{"name": "a", "weight": 1},
+				},
+			},
+			expect: map[string]interface{}{
+				"suspend": false,
+				"retry":   map[string]interface{}{"count": float64(3)},
+				"targets": []interface{}{
+					map[string]interface{}{"name": "a", "weight": float64(1)},
+				},
+			},
+		},
+		{
+			name:   "unknown fields kept",
+			obj:    map[string]interface{}{"extra": false},
+			expect: map[string]interface{}{"extra": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specSchema := testSpecSchema()
+			pruneDefaults(tt.obj, &specSchema, tt.applied)
+			if !reflect.DeepEqual(tt.obj, tt.expect) {
+				t.Errorf("pruneDefaults() = %v, expect %v", tt.obj, tt.expect)
+			}
+		})
+	}
+}
+
+func TestPruneExportDefaults(t *testing.T) {
+	withSchema := schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1beta1", Kind: "Kustomization"}
+	withoutSchema := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+	exportSchemas[withSchema] = &apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{"spec": testSpecSchema()},
+	}
+	exportSchemas[withoutSchema] = nil
+	defer func() {
+		delete(exportSchemas, withSchema)
+		delete(exportSchemas, withoutSchema)
+	}()
+
+	tests := []struct {
+		name   string
+		export map[string]interface{}
+		expect map[string]interface{}
+	}{
+		{
+			name: "defaults removed",
+			export: map[string]interface{}{
+				"apiVersion": withSchema.GroupVersion().String(),
+				"kind":       withSchema.Kind,
+				"spec":       map[string]interface{}{"interval": "1m", "suspend": false},
+			},
+			expect: map[string]interface{}{
+				"apiVersion": withSchema.GroupVersion().String(),
+				"kind":       withSchema.Kind,
+				"spec":       map[string]interface{}{"interval": "1m"},
+			},
+		},
+		{
+			name: "last applied defaults kept",
+			export: map[string]interface{}{
+				"apiVersion": withSchema.GroupVersion().String(),
+				"kind":       withSchema.Kind,
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						lastAppliedAnnotation: `{"spec":{"suspend":false}}`,
+					},
+				},
+				"spec": map[string]interface{}{"suspend": false, "prune": true},
+			},
+			expect: map[string]interface{}{
+				"apiVersion": withSchema.GroupVersion().String(),
+				"kind":       withSchema.Kind,
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						lastAppliedAnnotation: `{"spec":{"suspend":false}}`,
+					},
+				},
+				"spec": map[string]interface{}{"suspend": false},
+			},
+		},
+		{
+			name: "kind without a CRD unchanged",
+			export: map[string]interface{}{
+				"apiVersion": withoutSchema.GroupVersion().String(),
+				"kind":       withoutSchema.Kind,
+				"spec":       map[string]interface{}{"suspend": false},
+			},
+			expect: map[string]interface{}{
+				"apiVersion": withoutSchema.GroupVersion().String(),
+				"kind":       withoutSchema.Kind,
+				"spec":       map[string]interface{}{"suspend": false},
+			},
+		},
+		{
+			name: "core kind unchanged",
+			export: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"type":       "Opaque",
+			},
+			expect: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"type":       "Opaque",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pruneExportDefaults(tt.export)
+			if err != nil {
+				t.Fatalf("pruneExportDefaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("pruneExportDefaults() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
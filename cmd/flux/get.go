@@ -18,11 +18,29 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fluxcd/pkg/apis/meta"
@@ -33,18 +51,82 @@ import (
 var getCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Get sources and resources",
-	Long:  "The get sub-commands print the statuses of sources and resources.",
+	Long: `The get sub-commands print the statuses of sources and resources.
+
+With --output wide, the generation of each object and the generation last
+observed by its controller are added to the table. An observed generation
+lower than the generation is flagged as pending, the controller hasn't
+reconciled the latest change to the spec yet.
+
+With --output json --flatten, the objects are printed as an array with an
+entry for each object, holding the columns of the table as strings. The keys
+are the column names in lower camel case: 'namespace', 'name', 'ready' and
+'message', followed by the columns of the kind, e.g. 'revision', 'suspended'
+or 'lastScan'. 'flux get all' adds the 'kind' key.`,
 }
 
 type GetFlags struct {
-	allNamespaces bool
+	allNamespaces  bool
+	output         string
+	readyTimeout   time.Duration
+	watch          bool
+	selector       string
+	createdBy      string
+	createdByKey   string
+	truncate       int
+	noTruncate     bool
+	ageFormat      string
+	tableStyle     string
+	wrap           bool
+	noHeader       bool
+	flatten        bool
+	namespaceRegex string
+	showSensitive  bool
 }
 
 var getArgs GetFlags
 
+var supportedGetOutputFormats = []string{"wide", "json", "name", "csv", "summary-bar"}
+
+// jsonPathOutputPrefix is the prefix of the `--output jsonpath=<expr>`
+// format, which prints the result of the expression for each object.
+const jsonPathOutputPrefix = "jsonpath="
+
+var supportedGetAgeFormats = []string{"absolute", "relative"}
+
+var supportedGetTableStyles = []string{"plain", "bordered", "markdown"}
+
 func init() {
 	getCmd.PersistentFlags().BoolVarP(&getArgs.allNamespaces, "all-namespaces", "A", false,
 		"list the requested object(s) across all namespaces")
+	getCmd.PersistentFlags().StringVarP(&getArgs.output, "output", "o", "",
+		fmt.Sprintf("print the object(s) in the given format, available options are: (%s, %s<expr>)", strings.Join(supportedGetOutputFormats, ", "), jsonPathOutputPrefix))
+	getCmd.PersistentFlags().DurationVar(&getArgs.readyTimeout, "ready-timeout", 0,
+		"wait up to the given duration for the object(s) to be ready before printing them")
+	getCmd.PersistentFlags().BoolVarP(&getArgs.watch, "watch", "w", false,
+		"after listing the requested object(s), watch for changes, with '--output json' each change is printed as a JSON event on its own line")
+	getCmd.PersistentFlags().StringVarP(&getArgs.selector, "selector", "l", "",
+		"filter the object(s) by label selector, e.g. 'team=dev'")
+	getCmd.PersistentFlags().StringVar(&getArgs.createdBy, "created-by", "",
+		"filter the object(s) by the value of the annotation given by --created-by-annotation")
+	getCmd.PersistentFlags().StringVar(&getArgs.createdByKey, "created-by-annotation", "created-by",
+		"annotation key holding the creator of the object(s), used by --created-by")
+	getCmd.PersistentFlags().IntVar(&getArgs.truncate, "truncate", 0,
+		"elide the message column so the table fits in the given width, defaults to the terminal width")
+	getCmd.PersistentFlags().BoolVar(&getArgs.noTruncate, "no-truncate", false,
+		"print the full message column, regardless of the terminal width")
+	getCmd.PersistentFlags().BoolVar(&getArgs.wrap, "wrap", false,
+		"wrap the message column over multiple lines instead of eliding it, so the table fits in the width given by --truncate or the terminal")
+	getCmd.PersistentFlags().BoolVar(&getArgs.noHeader, "no-header", false,
+		"don't print the header row of the table or of the csv output")
+	getCmd.PersistentFlags().StringVar(&getArgs.namespaceRegex, "namespace-regex", "",
+		"with '--all-namespaces', list only the object(s) in the namespaces whose whole name matches the regular expression, e.g. 'team-.*'")
+	getCmd.PersistentFlags().BoolVar(&getArgs.flatten, "flatten", false,
+		"with '--output json', print an array holding the table columns of each object instead of the objects")
+	getCmd.PersistentFlags().StringVar(&getArgs.ageFormat, "age-format", "absolute",
+		fmt.Sprintf("how to print the time columns, as RFC3339 timestamps or as the time elapsed since, available options are: (%s)", strings.Join(supportedGetAgeFormats, ", ")))
+	getCmd.PersistentFlags().StringVar(&getArgs.tableStyle, "table-style", "plain",
+		fmt.Sprintf("the style of the printed tables, markdown renders GitHub-flavored pipe tables, available options are: (%s)", strings.Join(supportedGetTableStyles, ", ")))
 	rootCmd.AddCommand(getCmd)
 }
 
@@ -54,6 +136,32 @@ type summarisable interface {
 	headers(includeNamespace bool) []string
 }
 
+// emptyListing is implemented by listings that have their own message
+// for when no objects are found.
+type emptyListing interface {
+	emptyMessage() string
+}
+
+// redactable is implemented by listings whose objects have sensitive
+// fields, such as credentials, which are masked in every output format
+// unless `--show-sensitive` is set.
+type redactable interface {
+	redact(obj runtime.Object)
+}
+
+// showSensitiveFlagUsage is the usage of the --show-sensitive flag of
+// the commands that list redactable objects.
+const showSensitiveFlagUsage = "print the sensitive fields of the objects, such as the full address of the alert providers, " +
+	"instead of masking them in every output format"
+
+// wideSummarisable is implemented by listings that have additional
+// columns to show when using `--output wide`.
+type wideSummarisable interface {
+	summarisable
+	summariseItemWide(i int) []string
+	headersWide() []string
+}
+
 // --- these help with implementations of summarisable
 
 func statusAndMessage(conditions []metav1.Condition) (string, string) {
@@ -63,6 +171,14 @@ func statusAndMessage(conditions []metav1.Condition) (string, string) {
 	return string(metav1.ConditionFalse), "waiting to be reconciled"
 }
 
+// formatTime formats a time column according to the age format.
+func formatTime(t time.Time) string {
+	if getArgs.ageFormat == "relative" {
+		return duration.HumanDuration(time.Since(t))
+	}
+	return t.Format(time.RFC3339)
+}
+
 func nameColumns(item named, includeNamespace bool) []string {
 	if includeNamespace {
 		return []string{item.GetNamespace(), item.GetName()}
@@ -78,14 +194,226 @@ type getCommand struct {
 }
 
 func (get getCommand) run(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	if err := validateGetFlags(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout+getArgs.readyTimeout)
 	defer cancel()
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	kubeClient, err := utils.KubeWatchClient(rootArgs.kubeconfig, rootArgs.kubecontext)
 	if err != nil {
 		return err
 	}
 
+	listOpts, err := getListOptions(args)
+	if err != nil {
+		return err
+	}
+
+	err = listObjects(ctx, kubeClient, get.list, listOpts)
+	if err != nil {
+		return err
+	}
+
+	if get.list.len() == 0 && !getArgs.watch {
+		if l, ok := get.list.(emptyListing); ok {
+			logger.Failuref("%s in %s namespace", l.emptyMessage(), rootArgs.namespace)
+			return nil
+		}
+		logger.Failuref("no %s objects found in %s namespace", get.kind, rootArgs.namespace)
+		return nil
+	}
+
+	if getArgs.readyTimeout > 0 {
+		if err := get.waitForReady(ctx, kubeClient, listOpts); err != nil {
+			return err
+		}
+	}
+
+	if getArgs.watch {
+		return get.watchChanges(kubeClient, listOpts)
+	}
+
+	if getArgs.output == "json" {
+		if getArgs.flatten {
+			return printJSON(os.Stdout, get.flattenList())
+		}
+		return printJSON(os.Stdout, get.list.asClientList())
+	}
+
+	return get.printList()
+}
+
+// flattenList returns the table columns of each listed object, keyed
+// by the lower camel case column names, for `--output json --flatten`.
+func (get getCommand) flattenList() []map[string]string {
+	header := get.list.headers(true)
+	flattened := []map[string]string{}
+	for i := 0; i < get.list.len(); i++ {
+		row := get.list.summariseItem(i, true)
+		item := map[string]string{}
+		for j, column := range header {
+			item[flattenKey(column)] = row[j]
+		}
+		flattened = append(flattened, item)
+	}
+	return flattened
+}
+
+// flattenKey turns a column name like 'Last scan' into 'lastScan'.
+func flattenKey(column string) string {
+	words := strings.Fields(column)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = strings.Title(strings.ToLower(word))
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// printList prints the listed objects as a table, one identifier
+// per line with `--output name`, or a single line with the ratio of
+// ready objects with `--output summary-bar`.
+func (get getCommand) printList() error {
+	if getArgs.output == "summary-bar" {
+		ready, total, err := countReady(get.list.asClientList())
+		if err != nil {
+			return err
+		}
+		printSummaryBar(os.Stdout, ready, total)
+		return nil
+	}
+	if getArgs.output == "name" || isJSONPathOutput() {
+		items, err := apimeta.ExtractList(get.list.asClientList())
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := get.printLine(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return get.printTable()
+}
+
+// printLine prints the object on its own line, as its name or as the
+// result of the jsonpath expression.
+func (get getCommand) printLine(obj runtime.Object) error {
+	if isJSONPathOutput() {
+		return printJSONPath(obj)
+	}
+	return get.printName(obj)
+}
+
+// printName prints the object as `kind/name`, prefixed with the
+// namespace when listing across all namespaces.
+func (get getCommand) printName(obj runtime.Object) error {
+	accessor, err := apimeta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s/%s", strings.ToLower(get.kind), accessor.GetName())
+	if getArgs.allNamespaces {
+		name = fmt.Sprintf("%s/%s", accessor.GetNamespace(), name)
+	}
+	_, err = fmt.Fprintln(os.Stdout, name)
+	return err
+}
+
+// validateGetFlags returns an error if the output format, age format
+// or table style is not supported, or if the jsonpath expression can't
+// be parsed.
+func validateGetFlags() error {
+	if !utils.ContainsItemString(supportedGetTableStyles, getArgs.tableStyle) {
+		return fmt.Errorf("unsupported table style '%s', must be one of: %s",
+			getArgs.tableStyle, strings.Join(supportedGetTableStyles, ", "))
+	}
+	if !utils.ContainsItemString(supportedGetAgeFormats, getArgs.ageFormat) {
+		return fmt.Errorf("unsupported age format '%s', must be one of: %s",
+			getArgs.ageFormat, strings.Join(supportedGetAgeFormats, ", "))
+	}
+	if isJSONPathOutput() {
+		_, err := parseJSONPathOutput()
+		return err
+	}
+	if getArgs.output != "" && !utils.ContainsItemString(supportedGetOutputFormats, getArgs.output) {
+		return fmt.Errorf("unsupported output format '%s', must be one of: %s or %s<expr>",
+			getArgs.output, strings.Join(supportedGetOutputFormats, ", "), jsonPathOutputPrefix)
+	}
+	if getArgs.flatten && (getArgs.output != "json" || getArgs.watch) {
+		return fmt.Errorf("--flatten can only be used with --output json, and without --watch")
+	}
+	if getArgs.noHeader && getArgs.tableStyle == "markdown" {
+		return fmt.Errorf("--no-header can't be used with --table-style markdown, a markdown table must start with a header row")
+	}
+	if getArgs.wrap && getArgs.tableStyle == "markdown" {
+		return fmt.Errorf("--wrap can't be used with --table-style markdown, the cells of a markdown table can't span lines")
+	}
+	namespaceRegexp = nil
+	if getArgs.namespaceRegex != "" {
+		if !getArgs.allNamespaces {
+			return fmt.Errorf("--namespace-regex can only be used with --all-namespaces")
+		}
+		re, err := regexp.Compile("^(?:" + getArgs.namespaceRegex + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid namespace regex '%s': %w", getArgs.namespaceRegex, err)
+		}
+		namespaceRegexp = re
+	}
+	return nil
+}
+
+// namespaceRegexp is the compiled '--namespace-regex', set by
+// validateGetFlags.
+var namespaceRegexp *regexp.Regexp
+
+func isJSONPathOutput() bool {
+	return strings.HasPrefix(getArgs.output, jsonPathOutputPrefix)
+}
+
+// parseJSONPathOutput parses the expression of the jsonpath output
+// format. Like kubectl, the braces around the expression are optional.
+func parseJSONPathOutput() (*jsonpath.JSONPath, error) {
+	expr := strings.TrimPrefix(getArgs.output, jsonPathOutputPrefix)
+	if expr == "" {
+		return nil, fmt.Errorf("missing jsonpath expression, e.g. '%s{.metadata.name}'", jsonPathOutputPrefix)
+	}
+	if !strings.Contains(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	j := jsonpath.New("output").AllowMissingKeys(true)
+	if err := j.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression '%s': %w", expr, err)
+	}
+	return j, nil
+}
+
+// printJSONPath prints the result of the jsonpath expression for the
+// object on its own line.
+func printJSONPath(obj runtime.Object) error {
+	j, err := parseJSONPathOutput()
+	if err != nil {
+		return err
+	}
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	if err := j.Execute(os.Stdout, data); err != nil {
+		return fmt.Errorf("jsonpath evaluation failed: %w", err)
+	}
+	_, err = fmt.Fprintln(os.Stdout)
+	return err
+}
+
+// getListOptions returns the list options selecting the objects
+// requested by the get flags and the optional name argument.
+func getListOptions(args []string) ([]client.ListOption, error) {
 	var listOpts []client.ListOption
 	if !getArgs.allNamespaces {
 		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
@@ -95,22 +423,457 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 		listOpts = append(listOpts, client.MatchingFields{"metadata.name": args[0]})
 	}
 
-	err = kubeClient.List(ctx, get.list.asClientList(), listOpts...)
-	if err != nil {
-		return err
+	if getArgs.selector != "" {
+		selector, err := labels.Parse(getArgs.selector)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse selector '%s': %w", getArgs.selector, err)
+		}
+		listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: selector})
 	}
+	return listOpts, nil
+}
 
-	if get.list.len() == 0 {
-		logger.Failuref("no %s objects found in %s namespace", get.kind, rootArgs.namespace)
-		return nil
+func (get getCommand) printTable() error {
+	var items []int
+	for i := 0; i < get.list.len(); i++ {
+		items = append(items, i)
+	}
+	return get.printItems(items, getArgs.allNamespaces)
+}
+
+// printItems prints a table with the items at the given indices.
+func (get getCommand) printItems(items []int, includeNamespace bool) error {
+	wide, isWide := get.list.(wideSummarisable)
+	isWide = isWide && getArgs.output == "wide"
+
+	var generations [][]string
+	if getArgs.output == "wide" {
+		var err error
+		if generations, err = generationColumns(get.list.asClientList()); err != nil {
+			return err
+		}
 	}
 
-	header := get.list.headers(getArgs.allNamespaces)
+	header := get.list.headers(includeNamespace)
+	if isWide {
+		header = append(header, wide.headersWide()...)
+	}
+	if generations != nil {
+		header = append(header, "Generation", "Observed Generation")
+	}
 	var rows [][]string
-	for i := 0; i < get.list.len(); i++ {
-		row := get.list.summariseItem(i, getArgs.allNamespaces)
+	for _, i := range items {
+		row := get.list.summariseItem(i, includeNamespace)
+		if isWide {
+			row = append(row, wide.summariseItemWide(i)...)
+		}
+		if generations != nil {
+			row = append(row, generations[i]...)
+		}
 		rows = append(rows, row)
 	}
-	utils.PrintTable(os.Stdout, header, rows)
+	return printRows(header, rows)
+}
+
+// generationColumns returns the metadata.generation and the
+// status.observedGeneration of each item in the list. The observed
+// generation is flagged as pending when it is behind the generation.
+func generationColumns(list client.ObjectList) ([][]string, error) {
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	var columns [][]string
+	for _, item := range items {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+		generation, _, _ := unstructured.NestedInt64(obj, "metadata", "generation")
+		observed, _, _ := unstructured.NestedInt64(obj, "status", "observedGeneration")
+		observedColumn := strconv.FormatInt(observed, 10)
+		if observed < generation {
+			observedColumn += " (pending)"
+		}
+		columns = append(columns, []string{strconv.FormatInt(generation, 10), observedColumn})
+	}
+	return columns, nil
+}
+
+// printRows prints the rows as a table, or as CSV with `--output csv`,
+// quoting the fields as needed. The header row is left out with
+// `--no-header`.
+func printRows(header []string, rows [][]string) error {
+	if getArgs.output == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		if !getArgs.noHeader {
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+		return cw.WriteAll(rows)
+	}
+
+	fitMessages(header, rows)
+	if getArgs.noHeader {
+		header = nil
+	}
+	utils.PrintStyledTable(os.Stdout, header, rows, getArgs.tableStyle)
 	return nil
 }
+
+// minMessageWidth is the width below which messages are not truncated
+// any further.
+const minMessageWidth = 20
+
+// tabWidth is the distance between the tab stops of the terminal, used
+// to measure the padding between the columns of the plain table.
+const tabWidth = 8
+
+// fitMessages elides the message column of the rows, or wraps it
+// with `--wrap`, so that the table fits in the width given by
+// `--truncate` or the terminal. Messages are not truncated with
+// `--no-truncate`, or when the output is not a terminal and no width
+// is given.
+func fitMessages(header []string, rows [][]string) {
+	if getArgs.noTruncate {
+		return
+	}
+	width := getArgs.truncate
+	if width <= 0 {
+		w, _, err := terminal.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return
+		}
+		width = w
+	}
+
+	column := -1
+	for i, h := range header {
+		if h == "Message" {
+			column = i
+		}
+	}
+	if column < 0 {
+		return
+	}
+
+	widths := make([]int, len(header))
+	for i := range header {
+		widths[i] = utf8.RuneCountInString(header[i])
+		for _, row := range rows {
+			if i < len(row) && utf8.RuneCountInString(row[i]) > widths[i] {
+				widths[i] = utf8.RuneCountInString(row[i])
+			}
+		}
+	}
+
+	var used int
+	switch getArgs.tableStyle {
+	case "plain":
+		// every cell is padded to the width of its column and followed
+		// by a tab, which the terminal expands to the next tab stop
+		for i := 0; i < column; i++ {
+			used = (used + widths[i] + tabWidth) / tabWidth * tabWidth
+		}
+		for i := column + 1; i < len(header); i++ {
+			used += tabWidth + widths[i]
+		}
+		used += tabWidth
+	default:
+		// the cells are separated by " | " and the rows are framed by
+		// "| " and " |"
+		used = 3*(len(header)-1) + 4
+		for i := range header {
+			if i != column {
+				used += widths[i]
+			}
+		}
+	}
+
+	available := width - used
+	if available < minMessageWidth {
+		available = minMessageWidth
+	}
+	for _, row := range rows {
+		if getArgs.wrap {
+			row[column] = wrapMessage(row[column], available)
+			continue
+		}
+		if message := []rune(row[column]); len(message) > available {
+			row[column] = string(message[:available-1]) + "…"
+		}
+	}
+}
+
+// wrapMessage breaks the message into lines of at most width runes,
+// at spaces where possible.
+func wrapMessage(message string, width int) string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(message) {
+		w := []rune(word)
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// watchEvent is the JSON representation of a change printed when
+// watching with `--output json`.
+type watchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Object runtime.Object  `json:"object"`
+}
+
+// watchChanges prints the already listed objects and then watches
+// them for changes until the watch is closed. With JSON output every
+// object and change is printed as a watchEvent on its own line,
+// starting with an ADDED event for each existing object; otherwise
+// the table is printed again on every change.
+func (get getCommand) watchChanges(kubeClient client.WithWatch, listOpts []client.ListOption) error {
+	list := get.list.asClientList()
+	jsonOutput := getArgs.output == "json"
+
+	if jsonOutput {
+		items, err := apimeta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := printJSONLine(os.Stdout, watchEvent{Type: watch.Added, Object: item}); err != nil {
+				return err
+			}
+		}
+	} else if get.list.len() > 0 {
+		if err := get.printList(); err != nil {
+			return err
+		}
+	}
+
+	listAccessor, err := apimeta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+	watchOpts := append(listOpts, &client.ListOptions{
+		Raw: &metav1.ListOptions{ResourceVersion: listAccessor.GetResourceVersion()},
+	})
+
+	ctx := context.Background()
+	watcher, err := kubeClient.Watch(ctx, get.list.asClientList(), watchOpts...)
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for event := range watcher.ResultChan() {
+		if event.Type == watch.Error {
+			return apierrors.FromObject(event.Object)
+		}
+		if !matchesCreatedBy(event.Object) || !matchesNamespaceRegex(event.Object) {
+			continue
+		}
+		redactObject(get.list, event.Object)
+		if jsonOutput {
+			if err := printJSONLine(os.Stdout, watchEvent{Type: event.Type, Object: event.Object}); err != nil {
+				return err
+			}
+			continue
+		}
+		if getArgs.output == "name" || isJSONPathOutput() {
+			if err := get.printLine(event.Object); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := apimeta.SetList(get.list.asClientList(), []runtime.Object{}); err != nil {
+			return err
+		}
+		if err := listObjects(ctx, kubeClient, get.list, listOpts); err != nil {
+			return err
+		}
+		if err := get.printList(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForReady lists the objects until all of them are ready, or
+// the ready timeout has passed. Objects that are still not ready
+// after the timeout are not treated as an error.
+func (get getCommand) waitForReady(ctx context.Context, kubeClient client.Client, listOpts []client.ListOption) error {
+	logger.Waitingf("waiting for %s objects to be ready", get.kind)
+	err := wait.PollImmediate(rootArgs.pollInterval, getArgs.readyTimeout, func() (bool, error) {
+		list := get.list.asClientList()
+		if err := apimeta.SetList(list, []runtime.Object{}); err != nil {
+			return false, err
+		}
+		if err := listObjects(ctx, kubeClient, get.list, listOpts); err != nil {
+			return false, err
+		}
+		return allReady(list)
+	})
+	if err == wait.ErrWaitTimeout {
+		return nil
+	}
+	return err
+}
+
+// listObjects lists the objects matching the list options into list,
+// and drops the objects not matching the '--created-by' and
+// '--namespace-regex' filters, as they can't be applied server-side.
+// The sensitive fields of the objects are masked, so that no output
+// format prints them.
+func listObjects(ctx context.Context, kubeClient client.Client, list listAdapter, listOpts []client.ListOption) error {
+	if err := kubeClient.List(ctx, list.asClientList(), listOpts...); err != nil {
+		return err
+	}
+
+	items, err := apimeta.ExtractList(list.asClientList())
+	if err != nil {
+		return err
+	}
+	var filtered []runtime.Object
+	for _, item := range items {
+		if matchesCreatedBy(item) && matchesNamespaceRegex(item) {
+			redactObject(list, item)
+			filtered = append(filtered, item)
+		}
+	}
+	return apimeta.SetList(list.asClientList(), filtered)
+}
+
+// redactObject masks the sensitive fields of an object of the listing,
+// unless they are requested with `--show-sensitive`.
+func redactObject(list listAdapter, obj runtime.Object) {
+	if r, ok := list.(redactable); ok && !getArgs.showSensitive {
+		r.redact(obj)
+	}
+}
+
+// matchesCreatedBy reports whether the object is annotated with the
+// creator given by '--created-by', or if no creator is given.
+func matchesCreatedBy(obj runtime.Object) bool {
+	if getArgs.createdBy == "" {
+		return true
+	}
+	accessor, err := apimeta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	return accessor.GetAnnotations()[getArgs.createdByKey] == getArgs.createdBy
+}
+
+// matchesNamespaceRegex reports whether the namespace of the object
+// matches '--namespace-regex', or if no regex is given.
+func matchesNamespaceRegex(obj runtime.Object) bool {
+	if namespaceRegexp == nil {
+		return true
+	}
+	accessor, err := apimeta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	return namespaceRegexp.MatchString(accessor.GetNamespace())
+}
+
+// allReady reports whether every item in the list has a Ready
+// condition with status True. Items without status conditions are
+// considered ready.
+func allReady(list client.ObjectList) (bool, error) {
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		obj, ok := item.(interface {
+			GetStatusConditions() *[]metav1.Condition
+		})
+		if !ok {
+			continue
+		}
+		if !apimeta.IsStatusConditionTrue(*obj.GetStatusConditions(), meta.ReadyCondition) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// countReady returns the number of items in the list with a Ready
+// condition with status True, and the number of items. Items without
+// status conditions are counted as ready, as in allReady.
+func countReady(list client.ObjectList) (int, int, error) {
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return 0, 0, err
+	}
+	ready := 0
+	for _, item := range items {
+		obj, ok := item.(interface {
+			GetStatusConditions() *[]metav1.Condition
+		})
+		if !ok || apimeta.IsStatusConditionTrue(*obj.GetStatusConditions(), meta.ReadyCondition) {
+			ready++
+		}
+	}
+	return ready, len(items), nil
+}
+
+// summaryBarWidth is the number of cells of the summary bar.
+const summaryBarWidth = 20
+
+// printSummaryBar prints a bar with a cell filled for each ready
+// share of the objects, e.g. `████████████████░░░░ 12/15 ready`. The
+// bar is colored when writing to a terminal.
+func printSummaryBar(w io.Writer, ready, total int) {
+	filled := summaryBarWidth
+	if total > 0 {
+		filled = ready * summaryBarWidth / total
+	}
+	readyBar := strings.Repeat("█", filled)
+	notReadyBar := strings.Repeat("░", summaryBarWidth-filled)
+	if f, ok := w.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		readyBar = "\033[32m" + readyBar + "\033[0m"
+		if notReadyBar != "" {
+			notReadyBar = "\033[31m" + notReadyBar + "\033[0m"
+		}
+	}
+	fmt.Fprintf(w, "%s%s %d/%d ready\n", readyBar, notReadyBar, ready, total)
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func printJSONLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
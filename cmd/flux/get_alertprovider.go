@@ -17,17 +17,12 @@ limitations under the License.
 package main
 
 import (
-	"context"
-	"os"
+	"net/url"
 
 	"github.com/spf13/cobra"
-	apimeta "k8s.io/apimachinery/pkg/api/meta"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"k8s.io/apimachinery/pkg/runtime"
 
-	"github.com/fluxcd/flux2/internal/utils"
 	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
-	"github.com/fluxcd/pkg/apis/meta"
 )
 
 var getAlertProviderCmd = &cobra.Command{
@@ -37,63 +32,72 @@ var getAlertProviderCmd = &cobra.Command{
 	Long:    "The get alert-provider command prints the statuses of the resources.",
 	Example: `  # List all Providers and their status
   flux get alert-providers
+
+  # List all Providers including their type and address, with the path and credentials of the address masked
+  flux get alert-providers --output wide
+
+  # List all Providers including their full address
+  flux get alert-providers --output wide --show-sensitive
 `,
-	RunE: getAlertProviderCmdRun,
+	RunE: getCommand{
+		apiType: alertProviderType,
+		list:    &alertProviderListAdapter{&notificationv1.ProviderList{}},
+	}.run,
 }
 
 func init() {
+	getAlertProviderCmd.Flags().BoolVar(&getArgs.showSensitive, "show-sensitive", false, showSensitiveFlagUsage)
 	getCmd.AddCommand(getAlertProviderCmd)
 }
 
-func getAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
-	defer cancel()
+func (s alertProviderListAdapter) summariseItem(i int, includeNamespace bool) []string {
+	item := s.Items[i]
+	status, msg := statusAndMessage(item.Status.Conditions)
+	return append(nameColumns(&item, includeNamespace), status, msg)
+}
 
-	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
-	if err != nil {
-		return err
-	}
+func (s alertProviderListAdapter) emptyMessage() string {
+	return "no providers found"
+}
 
-	var listOpts []client.ListOption
-	if !getArgs.allNamespaces {
-		listOpts = append(listOpts, client.InNamespace(rootArgs.namespace))
-	}
-	var list notificationv1.ProviderList
-	err = kubeClient.List(ctx, &list, listOpts...)
-	if err != nil {
-		return err
+func (s alertProviderListAdapter) headers(includeNamespace bool) []string {
+	headers := []string{"Name", "Ready", "Message"}
+	if includeNamespace {
+		return append(namespaceHeader, headers...)
 	}
+	return headers
+}
 
-	if len(list.Items) == 0 {
-		logger.Failuref("no providers found in %s namespace", rootArgs.namespace)
-		return nil
+func (s alertProviderListAdapter) summariseItemWide(i int) []string {
+	item := s.Items[i]
+	return []string{item.Spec.Type, item.Spec.Address}
+}
+
+func (s alertProviderListAdapter) headersWide() []string {
+	return []string{"Type", "Address"}
+}
+
+// redact masks the address of the providers, as webhook URLs often
+// carry a token.
+func (s alertProviderListAdapter) redact(obj runtime.Object) {
+	if provider, ok := obj.(*notificationv1.Provider); ok {
+		provider.Spec.Address = maskAddress(provider.Spec.Address)
 	}
+}
 
-	header := []string{"Name", "Ready", "Message"}
-	if getArgs.allNamespaces {
-		header = append([]string{"Namespace"}, header...)
+// maskAddress keeps only the scheme and host of the address, as
+// webhook URLs often carry a token in their path or query.
+func maskAddress(address string) string {
+	if address == "" {
+		return ""
+	}
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		return "***"
 	}
-	var rows [][]string
-	for _, provider := range list.Items {
-		row := []string{}
-		if c := apimeta.FindStatusCondition(provider.Status.Conditions, meta.ReadyCondition); c != nil {
-			row = []string{
-				provider.GetName(),
-				string(c.Status),
-				c.Message,
-			}
-		} else {
-			row = []string{
-				provider.GetName(),
-				string(metav1.ConditionFalse),
-				"waiting to be reconciled",
-			}
-		}
-		if getArgs.allNamespaces {
-			row = append([]string{provider.Namespace}, row...)
-		}
-		rows = append(rows, row)
+	masked := url.URL{Scheme: u.Scheme, Host: u.Host}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		return masked.String() + "/***"
 	}
-	utils.PrintTable(os.Stdout, header, rows)
-	return nil
+	return masked.String()
 }
@@ -0,0 +1,402 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/fluxcd/flux2/internal/utils"
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	autov1 "github.com/fluxcd/image-automation-controller/api/v1alpha1"
+	imagev1 "github.com/fluxcd/image-reflector-controller/api/v1alpha1"
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+)
+
+var getAllCmd = &cobra.Command{
+	Use:   "all",
+	Short: "Get all resources and statuses",
+	Long:  "The get all command prints the statuses of all sources and resources.",
+	Example: `  # List all sources and resources in the flux-system namespace
+  flux get all
+
+  # List all sources and resources across all namespaces, grouped by namespace
+  flux get all --all-namespaces --group-by namespace
+
+  # List the sources and resources that are not ready across all namespaces
+  flux get all --all-namespaces --failed
+
+  # Print a one-line bar with the share of ready objects, e.g. for a terminal dashboard
+  flux get all --all-namespaces --output summary-bar
+
+  # List only the Kustomizations and the Git repositories
+  flux get all --only-kinds kustomization,gitrepository
+
+  # List all sources and resources except the image automation objects
+  flux get all --exclude-kinds imagerepository,imagepolicy,imageupdateautomation
+
+  # List all sources and resources in the namespaces of a team
+  flux get all --all-namespaces --namespace-regex 'team-a-.*'
+
+  # Print the table columns of all objects as a flat JSON array, e.g. for jq
+  flux get all --all-namespaces --output json --flatten
+`,
+	RunE: getAllCmdRun,
+}
+
+type getAllFlags struct {
+	groupBy      string
+	failed       bool
+	excludeKinds []string
+	onlyKinds    []string
+}
+
+var getAllArgs getAllFlags
+
+var supportedGetAllGroupBy = []string{"kind", "namespace"}
+
+func init() {
+	getAllCmd.Flags().StringVar(&getAllArgs.groupBy, "group-by", "kind",
+		fmt.Sprintf("group the objects by the given dimension, available options are: (%s)", strings.Join(supportedGetAllGroupBy, ", ")))
+	getAllCmd.Flags().BoolVar(&getAllArgs.failed, "failed", false,
+		"list only the objects that are not ready, regardless of their kind")
+	getAllCmd.Flags().StringSliceVar(&getAllArgs.excludeKinds, "exclude-kinds", nil,
+		"comma-separated list of kinds to leave out of the listing, e.g. 'helmchart,alert'")
+	getAllCmd.Flags().StringSliceVar(&getAllArgs.onlyKinds, "only-kinds", nil,
+		"comma-separated list of the only kinds to list, e.g. 'kustomization,gitrepository'")
+	getAllCmd.Flags().BoolVar(&getArgs.showSensitive, "show-sensitive", false, showSensitiveFlagUsage)
+	getCmd.AddCommand(getAllCmd)
+}
+
+// getAllCommands returns a get command with an empty list for each
+// of the kinds printed by `flux get all`.
+func getAllCommands() []getCommand {
+	return []getCommand{
+		{apiType: gitRepositoryType, list: &gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}}},
+		{apiType: bucketType, list: &bucketListAdapter{&sourcev1.BucketList{}}},
+		{apiType: helmRepositoryType, list: &helmRepositoryListAdapter{&sourcev1.HelmRepositoryList{}}},
+		{apiType: helmChartType, list: &helmChartListAdapter{&sourcev1.HelmChartList{}}},
+		{apiType: kustomizationType, list: &kustomizationListAdapter{&kustomizev1.KustomizationList{}}},
+		{apiType: helmReleaseType, list: &helmReleaseListAdapter{&helmv2.HelmReleaseList{}}},
+		{apiType: alertType, list: &alertListAdapter{&notificationv1.AlertList{}}},
+		{apiType: alertProviderType, list: &alertProviderListAdapter{&notificationv1.ProviderList{}}},
+		{apiType: receiverType, list: &receiverListAdapter{&notificationv1.ReceiverList{}}},
+		{apiType: imageRepositoryType, list: imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}}},
+		{apiType: imagePolicyType, list: &imagePolicyListAdapter{&imagev1.ImagePolicyList{}}},
+		{apiType: imageUpdateAutomationType, list: &imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}}},
+	}
+}
+
+// selectGetAllCommands returns the get commands of the kinds given by
+// `--only-kinds`, or of all kinds but those given by `--exclude-kinds`.
+// The kinds are matched case-insensitively.
+func selectGetAllCommands(onlyKinds, excludeKinds []string) ([]getCommand, error) {
+	if len(onlyKinds) > 0 && len(excludeKinds) > 0 {
+		return nil, fmt.Errorf("--only-kinds and --exclude-kinds can't be used together")
+	}
+
+	commands := getAllCommands()
+	var known []string
+	for _, get := range commands {
+		known = append(known, strings.ToLower(get.kind))
+	}
+	selected := map[string]bool{}
+	for _, kind := range append(onlyKinds, excludeKinds...) {
+		kind = strings.ToLower(kind)
+		if !utils.ContainsItemString(known, kind) {
+			return nil, fmt.Errorf("unsupported kind '%s', must be one of: %s", kind, strings.Join(known, ", "))
+		}
+		selected[kind] = true
+	}
+	if len(selected) == 0 {
+		return commands, nil
+	}
+
+	var filtered []getCommand
+	for _, get := range commands {
+		if selected[strings.ToLower(get.kind)] == (len(onlyKinds) > 0) {
+			filtered = append(filtered, get)
+		}
+	}
+	return filtered, nil
+}
+
+func getAllCmdRun(cmd *cobra.Command, args []string) error {
+	if !utils.ContainsItemString(supportedGetAllGroupBy, getAllArgs.groupBy) {
+		return fmt.Errorf("unsupported group by '%s', must be one of: %s",
+			getAllArgs.groupBy, strings.Join(supportedGetAllGroupBy, ", "))
+	}
+	if err := validateGetFlags(); err != nil {
+		return err
+	}
+	if getAllArgs.failed && (isJSONPathOutput() || getArgs.output == "summary-bar") {
+		return fmt.Errorf("--failed doesn't support the %s output format", getArgs.output)
+	}
+	commands, err := selectGetAllCommands(getAllArgs.onlyKinds, getAllArgs.excludeKinds)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return err
+	}
+
+	listOpts, err := getListOptions(args)
+	if err != nil {
+		return err
+	}
+
+	var found []getCommand
+	for _, get := range commands {
+		if err := listObjects(ctx, kubeClient, get.list, listOpts); err != nil {
+			// the CRDs of optional components may not be installed
+			if apimeta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		if get.list.len() > 0 {
+			found = append(found, get)
+		}
+	}
+
+	if len(found) == 0 {
+		logger.Failuref("no objects found in %s namespace", rootArgs.namespace)
+		return nil
+	}
+
+	if getArgs.flatten && !getAllArgs.failed {
+		flattened := []map[string]string{}
+		for _, get := range found {
+			for _, item := range get.flattenList() {
+				item["kind"] = get.kind
+				flattened = append(flattened, item)
+			}
+		}
+		return printJSON(os.Stdout, flattened)
+	}
+
+	if getAllArgs.failed || getArgs.output == "csv" {
+		return printAllStatuses(found, getAllArgs.failed)
+	}
+
+	if getArgs.output == "summary-bar" {
+		ready, total := 0, 0
+		for _, get := range found {
+			r, t, err := countReady(get.list.asClientList())
+			if err != nil {
+				return err
+			}
+			ready, total = ready+r, total+t
+		}
+		printSummaryBar(os.Stdout, ready, total)
+		return nil
+	}
+
+	if getAllArgs.groupBy == "namespace" && getArgs.output != "name" && !isJSONPathOutput() {
+		return printAllByNamespace(found)
+	}
+	return printAllByKind(found)
+}
+
+func printAllByKind(found []getCommand) error {
+	if getArgs.output == "json" {
+		byKind := map[string]runtime.Object{}
+		for _, get := range found {
+			byKind[get.kind] = get.list.asClientList()
+		}
+		return printJSON(os.Stdout, byKind)
+	}
+
+	if getArgs.output == "name" || isJSONPathOutput() {
+		for _, get := range found {
+			if err := get.printList(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i, get := range found {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		fmt.Fprintln(os.Stdout, get.kind)
+		if err := get.printTable(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printAllByNamespace prints a section for each namespace, with a
+// table for each kind that has objects in the namespace. In JSON the
+// objects are nested by namespace and kind.
+func printAllByNamespace(found []getCommand) error {
+	byNamespace := map[string]map[string][]int{}
+	objects := map[string][]runtime.Object{}
+	for _, get := range found {
+		items, err := apimeta.ExtractList(get.list.asClientList())
+		if err != nil {
+			return err
+		}
+		objects[get.kind] = items
+		for i, item := range items {
+			accessor, err := apimeta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			namespace := accessor.GetNamespace()
+			if byNamespace[namespace] == nil {
+				byNamespace[namespace] = map[string][]int{}
+			}
+			byNamespace[namespace][get.kind] = append(byNamespace[namespace][get.kind], i)
+		}
+	}
+
+	if getArgs.output == "json" {
+		nested := map[string]map[string][]runtime.Object{}
+		for namespace, kinds := range byNamespace {
+			nested[namespace] = map[string][]runtime.Object{}
+			for kind, items := range kinds {
+				for _, i := range items {
+					nested[namespace][kind] = append(nested[namespace][kind], objects[kind][i])
+				}
+			}
+		}
+		return printJSON(os.Stdout, nested)
+	}
+
+	var namespaces []string
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for n, namespace := range namespaces {
+		if n > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		fmt.Fprintf(os.Stdout, "Namespace: %s\n", namespace)
+		for _, get := range found {
+			items, ok := byNamespace[namespace][get.kind]
+			if !ok {
+				continue
+			}
+			fmt.Fprintln(os.Stdout)
+			fmt.Fprintln(os.Stdout, get.kind)
+			if err := get.printItems(items, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// objectStatus is the JSON representation of an object listed by
+// `flux get all --failed`, and the columns of `flux get all --output csv`.
+type objectStatus struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Ready     string `json:"ready"`
+	Message   string `json:"message"`
+}
+
+// printAllStatuses prints a single table with the objects of every
+// kind, or only those whose Ready condition is not true if failedOnly.
+// This is also how `--output csv` lists all kinds, as their standard
+// columns differ.
+func printAllStatuses(found []getCommand, failedOnly bool) error {
+	var statuses []objectStatus
+	for _, get := range found {
+		items, err := apimeta.ExtractList(get.list.asClientList())
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj, ok := item.(interface {
+				named
+				GetStatusConditions() *[]metav1.Condition
+			})
+			if !ok {
+				continue
+			}
+			status, msg := statusAndMessage(*obj.GetStatusConditions())
+			if failedOnly && status == string(metav1.ConditionTrue) {
+				continue
+			}
+			statuses = append(statuses, objectStatus{
+				Kind:      get.kind,
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+				Ready:     status,
+				Message:   msg,
+			})
+		}
+	}
+
+	switch getArgs.output {
+	case "json":
+		if statuses == nil {
+			statuses = []objectStatus{}
+		}
+		return printJSON(os.Stdout, statuses)
+	case "name":
+		for _, obj := range statuses {
+			name := fmt.Sprintf("%s/%s", strings.ToLower(obj.Kind), obj.Name)
+			if getArgs.allNamespaces {
+				name = fmt.Sprintf("%s/%s", obj.Namespace, name)
+			}
+			fmt.Fprintln(os.Stdout, name)
+		}
+		return nil
+	}
+
+	if len(statuses) == 0 && failedOnly {
+		logger.Successf("all objects are ready")
+		return nil
+	}
+
+	header := []string{"Kind", "Name", "Ready", "Message"}
+	if getArgs.allNamespaces {
+		header = append(namespaceHeader, header...)
+	}
+	var rows [][]string
+	for _, obj := range statuses {
+		row := []string{obj.Kind, obj.Name, obj.Ready, obj.Message}
+		if getArgs.allNamespaces {
+			row = append([]string{obj.Namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+	return printRows(header, rows)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -31,6 +32,9 @@ var getHelmReleaseCmd = &cobra.Command{
 	Long:    "The get helmreleases command prints the statuses of the resources.",
 	Example: `  # List all Helm releases and their status
   flux get helmreleases
+
+  # List all Helm releases including their chart source and chart name
+  flux get helmreleases --output wide
 `,
 	RunE: getCommand{
 		apiType: helmReleaseType,
@@ -57,3 +61,17 @@ func (a helmReleaseListAdapter) headers(includeNamespace bool) []string {
 	}
 	return headers
 }
+
+func (a helmReleaseListAdapter) summariseItemWide(i int) []string {
+	item := a.Items[i]
+	sourceRef := item.Spec.Chart.Spec.SourceRef
+	source := fmt.Sprintf("%s/%s", sourceRef.Kind, sourceRef.Name)
+	if sourceRef.Namespace != "" {
+		source = fmt.Sprintf("%s/%s/%s", sourceRef.Kind, sourceRef.Namespace, sourceRef.Name)
+	}
+	return []string{source, item.Spec.Chart.Spec.Chart}
+}
+
+func (a helmReleaseListAdapter) headersWide() []string {
+	return []string{"Source", "Chart"}
+}
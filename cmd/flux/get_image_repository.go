@@ -35,23 +35,59 @@ var getImageRepositoryCmd = &cobra.Command{
 
  # List image repositories from all namespaces
   flux get image repository --all-namespaces
+
+  # List image repositories including their interval, tag count and whether their last scan is stale
+  flux get image repository --output wide
+
+  # List image repositories with the time elapsed since their last scan
+  flux get image repository --age-format relative
 `,
-	RunE: getCommand{
-		apiType: imageRepositoryType,
-		list:    imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}},
-	}.run,
+	RunE: getImageRepositoryCmdRun,
 }
 
 func init() {
 	getImageCmd.AddCommand(getImageRepositoryCmd)
 }
 
+func getImageRepositoryCmdRun(cmd *cobra.Command, args []string) error {
+	list := imageRepositoryListAdapter{&imagev1.ImageRepositoryList{}}
+	err := getCommand{
+		apiType: imageRepositoryType,
+		list:    list,
+	}.run(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, item := range list.Items {
+		if imageRepositoryStale(item, now) {
+			logger.Warningf("ImageRepository %s/%s has not been scanned within its interval of %s",
+				item.Namespace, item.Name, item.Spec.Interval.Duration.String())
+		}
+	}
+	return nil
+}
+
+// imageRepositoryStale returns true if the image repository is not
+// suspended and its last successful scan is older than twice its
+// interval, which leaves room for the time a scan takes.
+func imageRepositoryStale(item imagev1.ImageRepository, now time.Time) bool {
+	if item.Spec.Suspend {
+		return false
+	}
+	if item.Status.LastScanResult == nil {
+		return true
+	}
+	return now.Sub(item.Status.LastScanResult.ScanTime.Time) > 2*item.Spec.Interval.Duration
+}
+
 func (s imageRepositoryListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := s.Items[i]
 	status, msg := statusAndMessage(item.Status.Conditions)
 	var lastScan string
 	if item.Status.LastScanResult != nil {
-		lastScan = item.Status.LastScanResult.ScanTime.Time.Format(time.RFC3339)
+		lastScan = formatTime(item.Status.LastScanResult.ScanTime.Time)
 	}
 	return append(nameColumns(&item, includeNamespace),
 		status, msg, lastScan, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
@@ -64,3 +100,17 @@ func (s imageRepositoryListAdapter) headers(includeNamespace bool) []string {
 	}
 	return headers
 }
+
+func (s imageRepositoryListAdapter) summariseItemWide(i int) []string {
+	item := s.Items[i]
+	var tags string
+	if item.Status.LastScanResult != nil {
+		tags = strconv.Itoa(item.Status.LastScanResult.TagCount)
+	}
+	return []string{item.Spec.Interval.Duration.String(), tags,
+		strings.Title(strconv.FormatBool(imageRepositoryStale(item, time.Now())))}
+}
+
+func (s imageRepositoryListAdapter) headersWide() []string {
+	return []string{"Interval", "Tags", "Stale"}
+}
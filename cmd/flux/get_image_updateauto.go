@@ -35,23 +35,56 @@ var getImageUpdateCmd = &cobra.Command{
 
  # List image update automations from all namespaces
   flux get image update --all-namespaces
+
+  # List image update automations including their interval and whether their last run is stale
+  flux get image update --output wide
 `,
-	RunE: getCommand{
-		apiType: imageUpdateAutomationType,
-		list:    &imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}},
-	}.run,
+	RunE: getImageUpdateCmdRun,
 }
 
 func init() {
 	getImageCmd.AddCommand(getImageUpdateCmd)
 }
 
+func getImageUpdateCmdRun(cmd *cobra.Command, args []string) error {
+	list := &imageUpdateAutomationListAdapter{&autov1.ImageUpdateAutomationList{}}
+	err := getCommand{
+		apiType: imageUpdateAutomationType,
+		list:    list,
+	}.run(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, item := range list.Items {
+		if imageUpdateAutomationStale(item, now) {
+			logger.Warningf("ImageUpdateAutomation %s/%s has not run within its interval of %s",
+				item.Namespace, item.Name, item.Spec.Interval.Duration.String())
+		}
+	}
+	return nil
+}
+
+// imageUpdateAutomationStale returns true if the automation is not
+// suspended and its last run is older than twice its interval, which
+// leaves room for the time a run takes.
+func imageUpdateAutomationStale(item autov1.ImageUpdateAutomation, now time.Time) bool {
+	if item.Spec.Suspend {
+		return false
+	}
+	if item.Status.LastAutomationRunTime == nil {
+		return true
+	}
+	return now.Sub(item.Status.LastAutomationRunTime.Time) > 2*item.Spec.Interval.Duration
+}
+
 func (s imageUpdateAutomationListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := s.Items[i]
 	status, msg := statusAndMessage(item.Status.Conditions)
 	var lastRun string
 	if item.Status.LastAutomationRunTime != nil {
-		lastRun = item.Status.LastAutomationRunTime.Time.Format(time.RFC3339)
+		lastRun = formatTime(item.Status.LastAutomationRunTime.Time)
 	}
 	return append(nameColumns(&item, includeNamespace), status, msg, lastRun, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
 }
@@ -63,3 +96,13 @@ func (s imageUpdateAutomationListAdapter) headers(includeNamespace bool) []strin
 	}
 	return headers
 }
+
+func (s imageUpdateAutomationListAdapter) summariseItemWide(i int) []string {
+	item := s.Items[i]
+	return []string{item.Spec.Interval.Duration.String(),
+		strings.Title(strconv.FormatBool(imageUpdateAutomationStale(item, time.Now())))}
+}
+
+func (s imageUpdateAutomationListAdapter) headersWide() []string {
+	return []string{"Interval", "Stale"}
+}
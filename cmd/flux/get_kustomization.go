@@ -17,11 +17,25 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var getKsCmd = &cobra.Command{
@@ -31,17 +45,417 @@ var getKsCmd = &cobra.Command{
 	Long:    "The get kustomizations command prints the statuses of the resources.",
 	Example: `  # List all kustomizations and their status
   flux get kustomizations
+
+  # List all kustomizations including their source and path
+  flux get kustomizations --output wide
+
+  # Stream changes to kustomizations as JSON events
+  flux get kustomizations --watch --output json
+
+  # List the kustomizations of a team created by the CI automation
+  flux get kustomizations --selector team=dev --created-by ci-bot
+
+  # Print the name of each kustomization, e.g. to pipe them into another command
+  flux get kustomizations --output name
+
+  # Print the revision applied by each kustomization
+  flux get kustomizations --output jsonpath='{.status.lastAppliedRevision}'
+
+  # List all kustomizations as a Markdown table, e.g. to paste it into an issue
+  flux get kustomizations --table-style markdown
+
+  # List all kustomizations across all namespaces in CSV format, e.g. for a spreadsheet
+  flux get kustomizations --all-namespaces --output csv > kustomizations.csv
+
+  # List all kustomizations, wrapping long messages over multiple lines
+  flux get kustomizations --wrap
+
+  # List all the conditions of a kustomization
+  flux get kustomizations my-app --conditions
+
+  # List the health check targets of each kustomization with their current status
+  flux get kustomizations --health-checks
+
+  # Print the dependencies of the kustomizations in all namespaces and report any dependency cycle
+  flux get kustomizations --depends-on-graph
 `,
-	RunE: getCommand{
-		apiType: kustomizationType,
-		list:    &kustomizationListAdapter{&kustomizev1.KustomizationList{}},
-	}.run,
+	RunE: getKsCmdRun,
+}
+
+type getKsFlags struct {
+	conditions     bool
+	healthChecks   bool
+	dependsOnGraph bool
 }
 
+var getKsArgs getKsFlags
+
 func init() {
+	getKsCmd.Flags().BoolVar(&getKsArgs.conditions, "conditions", false,
+		"print all the conditions of each kustomization with their status, reason and message")
+	getKsCmd.Flags().BoolVar(&getKsArgs.healthChecks, "health-checks", false,
+		"print the health check targets of each kustomization with their current status in the cluster")
+	getKsCmd.Flags().BoolVar(&getKsArgs.dependsOnGraph, "depends-on-graph", false,
+		"print the dependencies of the kustomizations in all namespaces, and fail if they contain a dependency cycle")
 	getCmd.AddCommand(getKsCmd)
 }
 
+func getKsCmdRun(cmd *cobra.Command, args []string) error {
+	modes := 0
+	for _, set := range []bool{getKsArgs.conditions, getKsArgs.healthChecks, getKsArgs.dependsOnGraph} {
+		if set {
+			modes++
+		}
+	}
+	if modes == 0 {
+		return getCommand{
+			apiType: kustomizationType,
+			list:    &kustomizationListAdapter{&kustomizev1.KustomizationList{}},
+		}.run(cmd, args)
+	}
+
+	if modes > 1 {
+		return fmt.Errorf("specify only one of --conditions, --health-checks or --depends-on-graph")
+	}
+	if getArgs.output == "csv" || getArgs.output == "summary-bar" {
+		return fmt.Errorf("--conditions, --health-checks and --depends-on-graph don't support the %s output format", getArgs.output)
+	}
+
+	if err := validateGetFlags(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return err
+	}
+
+	if getKsArgs.dependsOnGraph {
+		return printKsDependsOnGraph(ctx, kubeClient)
+	}
+
+	listOpts, err := getListOptions(args)
+	if err != nil {
+		return err
+	}
+
+	var list kustomizev1.KustomizationList
+	if err := listObjects(ctx, kubeClient, kustomizationListAdapter{&list}, listOpts); err != nil {
+		return err
+	}
+
+	if len(list.Items) == 0 {
+		logger.Failuref("no %s objects found in %s namespace", kustomizationType.kind, rootArgs.namespace)
+		return nil
+	}
+
+	if getKsArgs.healthChecks {
+		return printKsHealthChecks(ctx, kubeClient, list.Items)
+	}
+
+	if getArgs.output == "json" {
+		type kustomizationConditions struct {
+			Namespace  string             `json:"namespace"`
+			Name       string             `json:"name"`
+			Conditions []metav1.Condition `json:"conditions"`
+		}
+		var items []kustomizationConditions
+		for _, item := range list.Items {
+			items = append(items, kustomizationConditions{
+				Namespace:  item.Namespace,
+				Name:       item.Name,
+				Conditions: item.Status.Conditions,
+			})
+		}
+		return printJSON(os.Stdout, items)
+	}
+
+	header := []string{"Type", "Status", "Reason", "Message"}
+	for i, item := range list.Items {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		fmt.Fprintf(os.Stdout, "%s/%s\n", item.Namespace, item.Name)
+		var rows [][]string
+		for _, c := range item.Status.Conditions {
+			rows = append(rows, []string{c.Type, string(c.Status), c.Reason, c.Message})
+		}
+		utils.PrintStyledTable(os.Stdout, header, rows, getArgs.tableStyle)
+	}
+	return nil
+}
+
+// ksDependencies are the dependencies of a Kustomization, printed
+// with `--depends-on-graph`.
+type ksDependencies struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	DependsOn []string `json:"dependsOn"`
+	Missing   []string `json:"missing,omitempty"`
+}
+
+// printKsDependsOnGraph prints the dependencies of the Kustomizations
+// in all namespaces, as dependencies can cross namespaces, and returns
+// an error naming the Kustomizations of each dependency cycle.
+func printKsDependsOnGraph(ctx context.Context, kubeClient client.Client) error {
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return err
+	}
+
+	graph := map[string][]string{}
+	var nodes []ksDependencies
+	for _, ks := range list.Items {
+		graph[fmt.Sprintf("%s/%s", ks.Namespace, ks.Name)] = nil
+	}
+	for _, ks := range list.Items {
+		name := fmt.Sprintf("%s/%s", ks.Namespace, ks.Name)
+		node := ksDependencies{Namespace: ks.Namespace, Name: ks.Name, DependsOn: []string{}}
+		for _, dep := range ks.Spec.DependsOn {
+			namespace := dep.Namespace
+			if namespace == "" {
+				namespace = ks.Namespace
+			}
+			depName := fmt.Sprintf("%s/%s", namespace, dep.Name)
+			node.DependsOn = append(node.DependsOn, depName)
+			if _, found := graph[depName]; !found {
+				node.Missing = append(node.Missing, depName)
+				continue
+			}
+			graph[name] = append(graph[name], depName)
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Namespace != nodes[j].Namespace {
+			return nodes[i].Namespace < nodes[j].Namespace
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	cycles := dependencyCycles(graph)
+
+	if getArgs.output == "json" {
+		if cycles == nil {
+			cycles = [][]string{}
+		}
+		if err := printJSON(os.Stdout, struct {
+			Kustomizations []ksDependencies `json:"kustomizations"`
+			Cycles         [][]string       `json:"cycles"`
+		}{nodes, cycles}); err != nil {
+			return err
+		}
+	} else {
+		header := []string{"Name", "Depends on"}
+		var rows [][]string
+		for _, node := range nodes {
+			var deps []string
+			for _, dep := range node.DependsOn {
+				if utils.ContainsItemString(node.Missing, dep) {
+					dep += " (missing)"
+				}
+				deps = append(deps, dep)
+			}
+			rows = append(rows, []string{fmt.Sprintf("%s/%s", node.Namespace, node.Name), strings.Join(deps, ", ")})
+		}
+		if err := printRows(header, rows); err != nil {
+			return err
+		}
+	}
+
+	for _, cycle := range cycles {
+		logger.Failuref("dependency cycle: %s -> %s", strings.Join(cycle, " -> "), cycle[0])
+	}
+	if len(cycles) > 0 {
+		return fmt.Errorf("%d dependency cycles detected", len(cycles))
+	}
+	return nil
+}
+
+// dependencyCycles returns a cycle for each strongly connected
+// component of the graph that has more than one node or a node
+// depending on itself. Each cycle starts with its smallest node.
+func dependencyCycles(graph map[string][]string) [][]string {
+	var names []string
+	for name := range graph {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Tarjan's algorithm
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var components [][]string
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		lowLink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+		for _, dep := range graph[name] {
+			if _, visited := index[dep]; !visited {
+				visit(dep)
+				if lowLink[dep] < lowLink[name] {
+					lowLink[name] = lowLink[dep]
+				}
+			} else if onStack[dep] && index[dep] < lowLink[name] {
+				lowLink[name] = index[dep]
+			}
+		}
+		if lowLink[name] != index[name] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		components = append(components, component)
+	}
+	for _, name := range names {
+		if _, visited := index[name]; !visited {
+			visit(name)
+		}
+	}
+
+	var cycles [][]string
+	for _, component := range components {
+		if len(component) == 1 && !utils.ContainsItemString(graph[component[0]], component[0]) {
+			continue
+		}
+		sort.Strings(component)
+		cycles = append(cycles, cyclePath(graph, component))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// cyclePath returns the shortest path from the first node of the
+// strongly connected component back to itself.
+func cyclePath(graph map[string][]string, component []string) []string {
+	start := component[0]
+	parent := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dep := range graph[current] {
+			if !utils.ContainsItemString(component, dep) {
+				continue
+			}
+			if dep == start {
+				path := []string{current}
+				for path[0] != start {
+					path = append([]string{parent[path[0]]}, path...)
+				}
+				return path
+			}
+			if _, seen := parent[dep]; !seen {
+				parent[dep] = current
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return component
+}
+
+// ksHealthCheck is a health check target of a Kustomization with its
+// current status in the cluster.
+type ksHealthCheck struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+}
+
+// printKsHealthChecks prints the health check targets of each
+// kustomization, looking up their kstatus in the cluster.
+func printKsHealthChecks(ctx context.Context, kubeClient client.Client, items []kustomizev1.Kustomization) error {
+	type kustomizationHealthChecks struct {
+		Namespace    string          `json:"namespace"`
+		Name         string          `json:"name"`
+		HealthChecks []ksHealthCheck `json:"healthChecks"`
+	}
+	var results []kustomizationHealthChecks
+	for _, item := range items {
+		checks := []ksHealthCheck{}
+		for _, ref := range item.Spec.HealthChecks {
+			checks = append(checks, lookupHealthCheck(ctx, kubeClient, ref))
+		}
+		results = append(results, kustomizationHealthChecks{
+			Namespace:    item.Namespace,
+			Name:         item.Name,
+			HealthChecks: checks,
+		})
+	}
+
+	if getArgs.output == "json" {
+		return printJSON(os.Stdout, results)
+	}
+
+	header := []string{"Kind", "Namespace", "Name", "Status", "Message"}
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		fmt.Fprintf(os.Stdout, "%s/%s\n", result.Namespace, result.Name)
+		if len(result.HealthChecks) == 0 {
+			fmt.Fprintln(os.Stdout, "no health checks defined")
+			continue
+		}
+		var rows [][]string
+		for _, c := range result.HealthChecks {
+			rows = append(rows, []string{c.Kind, c.Namespace, c.Name, c.Status, c.Message})
+		}
+		utils.PrintStyledTable(os.Stdout, header, rows, getArgs.tableStyle)
+	}
+	return nil
+}
+
+// lookupHealthCheck fetches the target of a health check and computes
+// its kstatus, the same way kustomize-controller assesses its health.
+func lookupHealthCheck(ctx context.Context, kubeClient client.Client, ref meta.NamespacedObjectKindReference) ksHealthCheck {
+	check := ksHealthCheck{
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Namespace:  ref.Namespace,
+		Name:       ref.Name,
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+	namespacedName := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	if err := kubeClient.Get(ctx, namespacedName, obj); err != nil {
+		check.Status = status.NotFoundStatus.String()
+		if !apierrors.IsNotFound(err) {
+			check.Status = status.UnknownStatus.String()
+		}
+		check.Message = err.Error()
+		return check
+	}
+
+	result, err := status.Compute(obj)
+	if err != nil {
+		check.Status = status.UnknownStatus.String()
+		check.Message = err.Error()
+		return check
+	}
+	check.Status = result.Status.String()
+	check.Message = result.Message
+	return check
+}
+
 func (a kustomizationListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := a.Items[i]
 	revision := item.Status.LastAppliedRevision
@@ -57,3 +471,16 @@ func (a kustomizationListAdapter) headers(includeNamespace bool) []string {
 	}
 	return headers
 }
+
+func (a kustomizationListAdapter) summariseItemWide(i int) []string {
+	item := a.Items[i]
+	source := fmt.Sprintf("%s/%s", item.Spec.SourceRef.Kind, item.Spec.SourceRef.Name)
+	if item.Spec.SourceRef.Namespace != "" {
+		source = fmt.Sprintf("%s/%s/%s", item.Spec.SourceRef.Kind, item.Spec.SourceRef.Namespace, item.Spec.SourceRef.Name)
+	}
+	return []string{source, item.Spec.Path}
+}
+
+func (a kustomizationListAdapter) headersWide() []string {
+	return []string{"Source", "Path"}
+}
@@ -17,7 +17,21 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/apis/meta"
 )
 
 var getSourceCmd = &cobra.Command{
@@ -27,6 +41,163 @@ var getSourceCmd = &cobra.Command{
 	Long:    "The get source sub-commands print the statuses of the sources.",
 }
 
+type getSourceFlags struct {
+	probe bool
+}
+
+var getSourceArgs getSourceFlags
+
+// probeFlagUsage is the usage of the --probe flag of the sources that
+// have an endpoint.
+const probeFlagUsage = "after listing the sources, check from this machine that their endpoint can be reached, " +
+	"using the credentials of the referenced secret where supported"
+
 func init() {
 	getCmd.AddCommand(getSourceCmd)
 }
+
+// sourceProbe is the reachability check of the endpoint of a source.
+type sourceProbe struct {
+	namespace string
+	name      string
+	endpoint  string
+	probe     func(ctx context.Context) error
+}
+
+// validateSourceProbe returns an error if the endpoint probes can't be
+// printed along with the requested output.
+func validateSourceProbe() error {
+	if getArgs.watch || (getArgs.output != "" && getArgs.output != "wide") {
+		return fmt.Errorf("--probe can only be used with the table and wide outputs, and without --watch")
+	}
+	return nil
+}
+
+// printSourceProbes runs the probes one after the other and prints a
+// table with the outcome of each of them.
+func printSourceProbes(probes []sourceProbe) error {
+	if len(probes) == 0 {
+		return nil
+	}
+	logger.Actionf("probing source endpoints")
+	header := []string{"Name", "Endpoint", "Reachable", "Message"}
+	if getArgs.allNamespaces {
+		header = append(namespaceHeader, header...)
+	}
+	var rows [][]string
+	for _, p := range probes {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		reachable, msg := "True", "endpoint reachable"
+		if err := p.probe(ctx); err != nil {
+			reachable, msg = "False", err.Error()
+		}
+		cancel()
+		row := []string{p.name, p.endpoint, reachable, msg}
+		if getArgs.allNamespaces {
+			row = append([]string{p.namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+	fmt.Fprintln(os.Stdout)
+	return printRows(header, rows)
+}
+
+// probeHTTP sends a GET request to the address, with basic auth if a
+// username is given, and fails on error status codes.
+func probeHTTP(ctx context.Context, address, username, password string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned %s", address, resp.Status)
+	}
+	return nil
+}
+
+// probeTCP opens a connection to the host, adding the port if missing.
+func probeTCP(ctx context.Context, host, defaultPort string) error {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, defaultPort)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeGit checks that the Git server answers a reference discovery
+// request, as done by git ls-remote. For SSH only the connection to
+// the server is checked, the keys of the secret are not used.
+func probeGit(kubeClient client.Client, namespace, address string, secretRef *meta.LocalObjectReference) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		u, err := url.Parse(address)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "ssh" {
+			return probeTCP(ctx, u.Host, "22")
+		}
+		username, password, err := basicAuthFromSecret(ctx, kubeClient, namespace, secretRef)
+		if err != nil {
+			return err
+		}
+		return probeHTTP(ctx, strings.TrimSuffix(address, "/")+"/info/refs?service=git-upload-pack", username, password)
+	}
+}
+
+// probeHelmRepository checks that the index of the Helm repository
+// can be downloaded.
+func probeHelmRepository(kubeClient client.Client, namespace, address string, secretRef *meta.LocalObjectReference) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		username, password, err := basicAuthFromSecret(ctx, kubeClient, namespace, secretRef)
+		if err != nil {
+			return err
+		}
+		return probeHTTP(ctx, strings.TrimSuffix(address, "/")+"/index.yaml", username, password)
+	}
+}
+
+// probeBucket checks that the bucket endpoint answers HTTP requests,
+// the credentials are not verified as S3 requests must be signed.
+func probeBucket(endpoint string, insecure bool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		scheme := "https"
+		if insecure {
+			scheme = "http"
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, fmt.Sprintf("%s://%s/", scheme, endpoint), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+}
+
+// basicAuthFromSecret returns the username and password of the secret,
+// or empty values if no secret is referenced.
+func basicAuthFromSecret(ctx context.Context, kubeClient client.Client, namespace string, secretRef *meta.LocalObjectReference) (string, string, error) {
+	if secretRef == nil {
+		return "", "", nil
+	}
+	var secret corev1.Secret
+	namespacedName := types.NamespacedName{Namespace: namespace, Name: secretRef.Name}
+	if err := kubeClient.Get(ctx, namespacedName, &secret); err != nil {
+		return "", "", fmt.Errorf("secret '%s' can't be read: %w", secretRef.Name, err)
+	}
+	return string(secret.Data["username"]), string(secret.Data["password"]), nil
+}
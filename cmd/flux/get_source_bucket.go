@@ -33,17 +33,49 @@ var getSourceBucketCmd = &cobra.Command{
 
  # List buckets from all namespaces
   flux get sources helm --all-namespaces
+
+  # List buckets and check that their endpoint can be reached
+  flux get sources bucket --probe
 `,
-	RunE: getCommand{
-		apiType: bucketType,
-		list:    &bucketListAdapter{&sourcev1.BucketList{}},
-	}.run,
+	RunE: getSourceBucketCmdRun,
 }
 
 func init() {
+	getSourceBucketCmd.Flags().BoolVar(&getSourceArgs.probe, "probe", false, probeFlagUsage)
 	getSourceCmd.AddCommand(getSourceBucketCmd)
 }
 
+func getSourceBucketCmdRun(cmd *cobra.Command, args []string) error {
+	if getSourceArgs.probe {
+		if err := validateSourceProbe(); err != nil {
+			return err
+		}
+	}
+
+	list := &bucketListAdapter{&sourcev1.BucketList{}}
+	err := getCommand{
+		apiType: bucketType,
+		list:    list,
+	}.run(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if getSourceArgs.probe {
+		var probes []sourceProbe
+		for _, item := range list.Items {
+			probes = append(probes, sourceProbe{
+				namespace: item.Namespace,
+				name:      item.Name,
+				endpoint:  item.Spec.Endpoint,
+				probe:     probeBucket(item.Spec.Endpoint, item.Spec.Insecure),
+			})
+		}
+		return printSourceProbes(probes)
+	}
+	return nil
+}
+
 func (a *bucketListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := a.Items[i]
 	var revision string
@@ -17,11 +17,21 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/flags"
+	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var getSourceGitCmd = &cobra.Command{
@@ -31,19 +41,89 @@ var getSourceGitCmd = &cobra.Command{
 	Example: `  # List all Git repositories and their status
   flux get sources git
 
- # List Git repositories from all namespaces
+  # List Git repositories from all namespaces
   flux get sources git --all-namespaces
+
+  # List Git repositories including their ref, Git implementation and ignore rules
+  flux get sources git --output wide
+
+  # Warn about Git repositories fetched more often than every minute or less often than every hour
+  flux get sources git --interval-warn 1m:1h
+
+  # List Git repositories and check that their servers can be reached
+  flux get sources git --probe
+
+  # List the authentication method of each Git repository, inferred from its secret and URL
+  flux get sources git --auth-method
 `,
-	RunE: getCommand{
-		apiType: gitRepositoryType,
-		list:    &gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}},
-	}.run,
+	RunE: getSourceGitCmdRun,
+}
+
+type getSourceGitFlags struct {
+	intervalWarn flags.DurationRange
+	authMethod   bool
 }
 
+var getSourceGitArgs getSourceGitFlags
+
 func init() {
+	getSourceGitCmd.Flags().Var(&getSourceGitArgs.intervalWarn, "interval-warn",
+		"warn about Git repositories whose interval is outside the given "+getSourceGitArgs.intervalWarn.Description())
+	getSourceGitCmd.Flags().BoolVar(&getSourceArgs.probe, "probe", false, probeFlagUsage)
+	getSourceGitCmd.Flags().BoolVar(&getSourceGitArgs.authMethod, "auth-method", false,
+		"print the authentication method of each Git repository, inferred from the keys of the referenced secret and the URL scheme, "+
+			"and warn about private-looking URLs without credentials")
 	getSourceCmd.AddCommand(getSourceGitCmd)
 }
 
+func getSourceGitCmdRun(cmd *cobra.Command, args []string) error {
+	if getSourceGitArgs.authMethod {
+		return getSourceGitAuthMethods(args)
+	}
+
+	if getSourceArgs.probe {
+		if err := validateSourceProbe(); err != nil {
+			return err
+		}
+	}
+
+	list := &gitRepositoryListAdapter{&sourcev1.GitRepositoryList{}}
+	err := getCommand{
+		apiType: gitRepositoryType,
+		list:    list,
+	}.run(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if getSourceGitArgs.intervalWarn.String() != "" {
+		for _, item := range list.Items {
+			if !getSourceGitArgs.intervalWarn.Contains(item.Spec.Interval.Duration) {
+				logger.Warningf("GitRepository %s/%s interval %s is outside of %s",
+					item.Namespace, item.Name, item.Spec.Interval.Duration.String(), getSourceGitArgs.intervalWarn.String())
+			}
+		}
+	}
+
+	if getSourceArgs.probe {
+		kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+		if err != nil {
+			return err
+		}
+		var probes []sourceProbe
+		for _, item := range list.Items {
+			probes = append(probes, sourceProbe{
+				namespace: item.Namespace,
+				name:      item.Name,
+				endpoint:  item.Spec.URL,
+				probe:     probeGit(kubeClient, item.Namespace, item.Spec.URL, item.Spec.SecretRef),
+			})
+		}
+		return printSourceProbes(probes)
+	}
+	return nil
+}
+
 func (a *gitRepositoryListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := a.Items[i]
 	var revision string
@@ -52,13 +132,184 @@ func (a *gitRepositoryListAdapter) summariseItem(i int, includeNamespace bool) [
 	}
 	status, msg := statusAndMessage(item.Status.Conditions)
 	return append(nameColumns(&item, includeNamespace),
-		status, msg, revision, strings.Title(strconv.FormatBool(item.Spec.Suspend)))
+		status, msg, revision, item.Spec.Interval.Duration.String(), strings.Title(strconv.FormatBool(item.Spec.Suspend)))
 }
 
 func (a gitRepositoryListAdapter) headers(includeNamespace bool) []string {
-	headers := []string{"Name", "Ready", "Message", "Revision", "Suspended"}
+	headers := []string{"Name", "Ready", "Message", "Revision", "Interval", "Suspended"}
 	if includeNamespace {
 		headers = append([]string{"Namespace"}, headers...)
 	}
 	return headers
 }
+
+func (a gitRepositoryListAdapter) summariseItemWide(i int) []string {
+	item := a.Items[i]
+	var ref string
+	if r := item.Spec.Reference; r != nil {
+		switch {
+		case r.Commit != "":
+			ref = "commit/" + r.Commit
+		case r.SemVer != "":
+			ref = "semver/" + r.SemVer
+		case r.Tag != "":
+			ref = "tag/" + r.Tag
+		case r.Branch != "":
+			ref = "branch/" + r.Branch
+		}
+	}
+	implementation := item.Spec.GitImplementation
+	if implementation == "" {
+		implementation = sourcev1.GoGitImplementation
+	}
+	return []string{ref, implementation, strings.Title(strconv.FormatBool(item.Spec.Ignore != nil))}
+}
+
+func (a gitRepositoryListAdapter) headersWide() []string {
+	return []string{"Ref", "Implementation", "Ignore"}
+}
+
+// gitAuthMethod is the authentication method of a GitRepository, as
+// inferred from its secret and URL.
+type gitAuthMethod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Secret    string `json:"secret,omitempty"`
+	Method    string `json:"method"`
+	Warning   string `json:"warning,omitempty"`
+}
+
+func getSourceGitAuthMethods(args []string) error {
+	if getSourceArgs.probe {
+		return fmt.Errorf("--auth-method and --probe can't be used together")
+	}
+	if getArgs.watch || (getArgs.output != "" && getArgs.output != "json") {
+		return fmt.Errorf("--auth-method can only be used with the table and json outputs, and without --watch")
+	}
+	if err := validateGetFlags(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+	defer cancel()
+
+	kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+	if err != nil {
+		return err
+	}
+
+	listOpts, err := getListOptions(args)
+	if err != nil {
+		return err
+	}
+
+	var list sourcev1.GitRepositoryList
+	if err := listObjects(ctx, kubeClient, gitRepositoryListAdapter{&list}, listOpts); err != nil {
+		return err
+	}
+
+	if len(list.Items) == 0 {
+		logger.Failuref("no %s objects found in %s namespace", gitRepositoryType.kind, rootArgs.namespace)
+		return nil
+	}
+
+	results := []gitAuthMethod{}
+	for _, item := range list.Items {
+		result := inferGitAuthMethod(ctx, kubeClient, item)
+		if result.Warning != "" {
+			logger.Warningf("GitRepository %s/%s %s", item.Namespace, item.Name, result.Warning)
+		}
+		results = append(results, result)
+	}
+
+	if getArgs.output == "json" {
+		return printJSON(os.Stdout, results)
+	}
+
+	header := []string{"Name", "URL", "Secret", "Auth method"}
+	if getArgs.allNamespaces {
+		header = append([]string{"Namespace"}, header...)
+	}
+	var rows [][]string
+	for _, r := range results {
+		row := []string{r.Name, r.URL, r.Secret, r.Method}
+		if getArgs.allNamespaces {
+			row = append([]string{r.Namespace}, row...)
+		}
+		rows = append(rows, row)
+	}
+	return printRows(header, rows)
+}
+
+// inferGitAuthMethod looks at the keys of the secret referenced by the
+// GitRepository, the same keys source-controller reads, to tell SSH,
+// basic-auth and token authentication apart.
+func inferGitAuthMethod(ctx context.Context, kubeClient client.Client, item sourcev1.GitRepository) gitAuthMethod {
+	result := gitAuthMethod{
+		Namespace: item.Namespace,
+		Name:      item.Name,
+		URL:       item.Spec.URL,
+	}
+
+	u, err := url.Parse(item.Spec.URL)
+	if err != nil {
+		result.Method = "unknown"
+		result.Warning = fmt.Sprintf("URL can't be parsed: %s", err.Error())
+		return result
+	}
+
+	if item.Spec.SecretRef == nil {
+		switch {
+		case u.User != nil && urlHasPassword(u):
+			result.Method = "url-credentials"
+			result.Warning = "has credentials embedded in its URL instead of a secret"
+		case u.Scheme == "ssh":
+			result.Method = "none"
+			result.Warning = "has an SSH URL but no secret, SSH requires an identity"
+		case u.User != nil:
+			result.Method = "none"
+			result.Warning = fmt.Sprintf("URL names the user '%s' but no secret is referenced", u.User.Username())
+		default:
+			result.Method = "none"
+		}
+		return result
+	}
+
+	result.Secret = item.Spec.SecretRef.Name
+	var secret corev1.Secret
+	namespacedName := types.NamespacedName{Namespace: item.Namespace, Name: item.Spec.SecretRef.Name}
+	if err := kubeClient.Get(ctx, namespacedName, &secret); err != nil {
+		result.Method = "unknown"
+		result.Warning = fmt.Sprintf("secret '%s' can't be read: %s", item.Spec.SecretRef.Name, err.Error())
+		return result
+	}
+
+	_, hasIdentity := secret.Data["identity"]
+	_, hasUsername := secret.Data["username"]
+	_, hasPassword := secret.Data["password"]
+	switch {
+	case hasIdentity:
+		result.Method = "ssh"
+		if u.Scheme != "ssh" {
+			result.Warning = fmt.Sprintf("secret '%s' holds an SSH identity but the URL scheme is '%s'", secret.Name, u.Scheme)
+		}
+	case hasUsername && hasPassword:
+		result.Method = "basic-auth"
+	case hasPassword:
+		result.Method = "token"
+	default:
+		result.Method = "unknown"
+		result.Warning = fmt.Sprintf("secret '%s' has neither an identity nor a password", secret.Name)
+	}
+
+	if (result.Method == "basic-auth" || result.Method == "token") && u.Scheme != "https" {
+		result.Warning = fmt.Sprintf("sends its %s credentials over a '%s' URL", result.Method, u.Scheme)
+	}
+	return result
+}
+
+func urlHasPassword(u *url.URL) bool {
+	_, ok := u.User.Password()
+	return ok
+}
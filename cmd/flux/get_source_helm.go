@@ -22,6 +22,8 @@ import (
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/spf13/cobra"
+
+	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var getSourceHelmCmd = &cobra.Command{
@@ -33,17 +35,53 @@ var getSourceHelmCmd = &cobra.Command{
 
  # List Helm repositories from all namespaces
   flux get sources helm --all-namespaces
+
+  # List Helm repositories and check that their index can be downloaded
+  flux get sources helm --probe
 `,
-	RunE: getCommand{
-		apiType: helmRepositoryType,
-		list:    &helmRepositoryListAdapter{&sourcev1.HelmRepositoryList{}},
-	}.run,
+	RunE: getSourceHelmCmdRun,
 }
 
 func init() {
+	getSourceHelmCmd.Flags().BoolVar(&getSourceArgs.probe, "probe", false, probeFlagUsage)
 	getSourceCmd.AddCommand(getSourceHelmCmd)
 }
 
+func getSourceHelmCmdRun(cmd *cobra.Command, args []string) error {
+	if getSourceArgs.probe {
+		if err := validateSourceProbe(); err != nil {
+			return err
+		}
+	}
+
+	list := &helmRepositoryListAdapter{&sourcev1.HelmRepositoryList{}}
+	err := getCommand{
+		apiType: helmRepositoryType,
+		list:    list,
+	}.run(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if getSourceArgs.probe {
+		kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+		if err != nil {
+			return err
+		}
+		var probes []sourceProbe
+		for _, item := range list.Items {
+			probes = append(probes, sourceProbe{
+				namespace: item.Namespace,
+				name:      item.Name,
+				endpoint:  item.Spec.URL,
+				probe:     probeHelmRepository(kubeClient, item.Namespace, item.Spec.URL, item.Spec.SecretRef),
+			})
+		}
+		return printSourceProbes(probes)
+	}
+	return nil
+}
+
 func (a *helmRepositoryListAdapter) summariseItem(i int, includeNamespace bool) []string {
 	item := a.Items[i]
 	var revision string
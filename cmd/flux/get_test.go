@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	notificationv1 "github.com/fluxcd/notification-controller/api/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFitMessages(t *testing.T) {
+	defaultArgs := getArgs
+	defer func() {
+		getArgs = defaultArgs
+	}()
+
+	tests := []struct {
+		name       string
+		tableStyle string
+		truncate   int
+		noTruncate bool
+		row        []string
+		expect     string
+	}{
+		{
+			name:       "fits",
+			tableStyle: "plain",
+			truncate:   80,
+			row:        []string{"podinfo", "True", "Applied revision"},
+			expect:     "Applied revision",
+		},
+		{
+			name:       "elided at the tab stops",
+			tableStyle: "plain",
+			truncate:   44,
+			row:        []string{"podinfo", "True", strings.Repeat("a", 30)},
+			expect:     strings.Repeat("a", 19) + "…",
+		},
+		{
+			name:       "non-ASCII columns measured in runes",
+			tableStyle: "plain",
+			truncate:   52,
+			row:        []string{"ééééééé", "True", strings.Repeat("ü", 40)},
+			expect:     strings.Repeat("ü", 27) + "…",
+		},
+		{
+			name:       "bordered",
+			tableStyle: "bordered",
+			truncate:   52,
+			row:        []string{"podinfo", "True", strings.Repeat("a", 40)},
+			expect:     strings.Repeat("a", 29) + "…",
+		},
+		{
+			name:       "not below the minimum width",
+			tableStyle: "plain",
+			truncate:   10,
+			row:        []string{"podinfo", "True", strings.Repeat("a", 40)},
+			expect:     strings.Repeat("a", minMessageWidth-1) + "…",
+		},
+		{
+			name:       "no truncate",
+			tableStyle: "plain",
+			truncate:   10,
+			noTruncate: true,
+			row:        []string{"podinfo", "True", strings.Repeat("a", 40)},
+			expect:     strings.Repeat("a", 40),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getArgs = defaultArgs
+			getArgs.tableStyle = tt.tableStyle
+			getArgs.truncate = tt.truncate
+			getArgs.noTruncate = tt.noTruncate
+			getArgs.wrap = false
+
+			rows := [][]string{tt.row}
+			fitMessages([]string{"Name", "Ready", "Message"}, rows)
+			if got := rows[0][2]; got != tt.expect {
+				t.Errorf("fitMessages() = %q, expect %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestWrapMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		width   int
+		expect  string
+	}{
+		{"fits", "Applied revision", 20, "Applied revision"},
+		{"at spaces", "the quick brown fox jumps", 10, "the quick\nbrown fox\njumps"},
+		{"long word", "abcdefghijkl", 5, "abcde\nfghij\nkl"},
+		{"long word after a short one", "go abcdefgh", 4, "go\nabcd\nefgh"},
+		{"collapsed spaces", "a  b", 10, "a b"},
+		{"runes", "ééé üüü", 3, "ééé\nüüü"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapMessage(tt.message, tt.width); got != tt.expect {
+				t.Errorf("wrapMessage() = %q, expect %q", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestFlattenKey(t *testing.T) {
+	tests := []struct {
+		column string
+		expect string
+	}{
+		{"Name", "name"},
+		{"Last scan", "lastScan"},
+		{"Observed Generation", "observedGeneration"},
+		{"URL", "url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			if got := flattenKey(tt.column); got != tt.expect {
+				t.Errorf("flattenKey(%q) = %q, expect %q", tt.column, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestFlattenList(t *testing.T) {
+	get := getCommand{
+		apiType: alertType,
+		list: &alertListAdapter{&notificationv1.AlertList{
+			Items: []notificationv1.Alert{
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "slack"},
+					Status: notificationv1.AlertStatus{
+						Conditions: []metav1.Condition{
+							{Type: meta.ReadyCondition, Status: metav1.ConditionTrue, Message: "Initialized"},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "teams"},
+					Spec:       notificationv1.AlertSpec{Suspend: true},
+				},
+			},
+		}},
+	}
+
+	expect := []map[string]string{
+		{"namespace": "flux-system", "name": "slack", "ready": "True", "message": "Initialized", "suspended": "False"},
+		{"namespace": "apps", "name": "teams", "ready": "False", "message": "waiting to be reconciled", "suspended": "True"},
+	}
+	if got := get.flattenList(); !reflect.DeepEqual(got, expect) {
+		t.Errorf("flattenList() = %v, expect %v", got, expect)
+	}
+}
+
+func TestGenerationColumns(t *testing.T) {
+	list := &sourcev1.GitRepositoryList{
+		Items: []sourcev1.GitRepository{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "reconciled", Generation: 3},
+				Status:     sourcev1.GitRepositoryStatus{ObservedGeneration: 3},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "pending", Generation: 4},
+				Status:     sourcev1.GitRepositoryStatus{ObservedGeneration: 2},
+			},
+		},
+	}
+
+	got, err := generationColumns(list)
+	if err != nil {
+		t.Fatalf("generationColumns() error = %v", err)
+	}
+	expect := [][]string{{"3", "3"}, {"4", "2 (pending)"}}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("generationColumns() = %v, expect %v", got, expect)
+	}
+}
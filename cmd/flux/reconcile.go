@@ -19,6 +19,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/fluxcd/pkg/apis/meta"
@@ -26,6 +28,7 @@ import (
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -39,7 +42,20 @@ var reconcileCmd = &cobra.Command{
 	Long:  "The reconcile sub-commands trigger a reconciliation of sources and resources.",
 }
 
+type reconcileFlags struct {
+	output        string
+	annotationKey string
+}
+
+var reconcileArgs reconcileFlags
+
+var supportedReconcileOutputFormats = []string{"json"}
+
 func init() {
+	reconcileCmd.PersistentFlags().StringVarP(&reconcileArgs.output, "output", "o", "",
+		fmt.Sprintf("print the result of each reconciliation in the given format, available options are: (%s)", strings.Join(supportedReconcileOutputFormats, ", ")))
+	reconcileCmd.PersistentFlags().StringVar(&reconcileArgs.annotationKey, "annotation-key", meta.ReconcileRequestAnnotation,
+		"the annotation used to request a reconciliation, for custom resources built on the GitOps Toolkit")
 	rootCmd.AddCommand(reconcileCmd)
 }
 
@@ -63,12 +79,70 @@ type reconcilable interface {
 	successMessage() string              // what do you want to tell people when successfully reconciled?
 }
 
+// revisioned is implemented by reconcilables that report the revision
+// they last reconciled.
+type revisioned interface {
+	lastRevision() string
+}
+
+// reconcileResult is the outcome of a reconciliation, printed with
+// `--output json`.
+type reconcileResult struct {
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	OldRevision string `json:"oldRevision,omitempty"`
+	NewRevision string `json:"newRevision,omitempty"`
+	Ready       string `json:"ready"`
+	Message     string `json:"message"`
+	Elapsed     string `json:"elapsed"`
+}
+
+func validateReconcileFlags() error {
+	if reconcileArgs.output != "" && !utils.ContainsItemString(supportedReconcileOutputFormats, reconcileArgs.output) {
+		return fmt.Errorf("unsupported output format '%s', must be one of: %s",
+			reconcileArgs.output, strings.Join(supportedReconcileOutputFormats, ", "))
+	}
+	if reconcileArgs.annotationKey == "" {
+		return fmt.Errorf("annotation key can't be empty")
+	}
+	if err := validation.IsQualifiedName(reconcileArgs.annotationKey); len(err) > 0 {
+		return fmt.Errorf("invalid annotation key '%s': %v", reconcileArgs.annotationKey, err)
+	}
+	return nil
+}
+
+// printReconcileResult prints the outcome of a reconciliation that
+// started at the given time, if an output format is requested.
+func printReconcileResult(kind string, namespacedName types.NamespacedName, oldRevision, newRevision string,
+	conditions []metav1.Condition, start time.Time) error {
+	if reconcileArgs.output == "" {
+		return nil
+	}
+	ready, message := statusAndMessage(conditions)
+	return printJSON(os.Stdout, reconcileResult{
+		Kind:        kind,
+		Namespace:   namespacedName.Namespace,
+		Name:        namespacedName.Name,
+		OldRevision: oldRevision,
+		NewRevision: newRevision,
+		Ready:       ready,
+		Message:     message,
+		Elapsed:     time.Since(start).Round(time.Millisecond).String(),
+	})
+}
+
 func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("%s name is required", reconcile.kind)
 	}
 	name := args[0]
 
+	if err := validateReconcileFlags(); err != nil {
+		return err
+	}
+	start := time.Now()
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -91,6 +165,11 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resource is suspended")
 	}
 
+	var oldRevision string
+	if obj, ok := reconcile.object.(revisioned); ok {
+		oldRevision = obj.lastRevision()
+	}
+
 	logger.Actionf("annotating %s %s in %s namespace", reconcile.kind, name, rootArgs.namespace)
 	if err := requestReconciliation(ctx, kubeClient, namespacedName, reconcile.object); err != nil {
 		return err
@@ -99,16 +178,33 @@ func (reconcile reconcileCommand) run(cmd *cobra.Command, args []string) error {
 
 	lastHandledReconcileAt := reconcile.object.lastHandledReconcileRequest()
 	logger.Waitingf("waiting for %s reconciliation", reconcile.kind)
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		reconciliationHandled(ctx, kubeClient, namespacedName, reconcile.object, lastHandledReconcileAt)); err != nil {
+	waitErr := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		reconciliationHandled(ctx, kubeClient, namespacedName, reconcile.object, lastHandledReconcileAt))
+
+	var newRevision string
+	if obj, ok := reconcile.object.(revisioned); ok {
+		newRevision = obj.lastRevision()
+	}
+	if err := printReconcileResult(reconcile.kind, namespacedName, oldRevision, newRevision,
+		*reconcile.object.GetStatusConditions(), start); err != nil {
 		return err
 	}
+	if waitErr != nil {
+		return waitErr
+	}
 	logger.Successf("%s reconciliation completed", reconcile.kind)
 
 	if apimeta.IsStatusConditionFalse(*reconcile.object.GetStatusConditions(), meta.ReadyCondition) {
 		return fmt.Errorf("%s reconciliation failed", reconcile.kind)
 	}
 	logger.Successf(reconcile.object.successMessage())
+	if _, ok := reconcile.object.(revisioned); ok && oldRevision != "" {
+		if newRevision == oldRevision {
+			logger.Successf("no new revision, %s is up to date", reconcile.kind)
+		} else {
+			logger.Successf("revision changed from %s to %s", oldRevision, newRevision)
+		}
+	}
 	return nil
 }
 
@@ -131,10 +227,10 @@ func requestReconciliation(ctx context.Context, kubeClient client.Client,
 		}
 		if ann := obj.GetAnnotations(); ann == nil {
 			obj.SetAnnotations(map[string]string{
-				meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+				reconcileArgs.annotationKey: time.Now().Format(time.RFC3339Nano),
 			})
 		} else {
-			ann[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+			ann[reconcileArgs.annotationKey] = time.Now().Format(time.RFC3339Nano)
 			obj.SetAnnotations(ann)
 		}
 		return kubeClient.Update(ctx, obj.asClientObject())
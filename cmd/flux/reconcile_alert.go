@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/fluxcd/flux2/internal/utils"
-	"github.com/fluxcd/pkg/apis/meta"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/types"
@@ -51,6 +50,11 @@ func reconcileAlertCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
+	if err := validateReconcileFlags(); err != nil {
+		return err
+	}
+	start := time.Now()
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -77,10 +81,10 @@ func reconcileAlertCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Actionf("annotating Alert %s in %s namespace", name, rootArgs.namespace)
 	if alert.Annotations == nil {
 		alert.Annotations = map[string]string{
-			meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+			reconcileArgs.annotationKey: time.Now().Format(time.RFC3339Nano),
 		}
 	} else {
-		alert.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+		alert.Annotations[reconcileArgs.annotationKey] = time.Now().Format(time.RFC3339Nano)
 	}
 
 	if err := kubeClient.Update(ctx, &alert); err != nil {
@@ -89,10 +93,14 @@ func reconcileAlertCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("Alert annotated")
 
 	logger.Waitingf("waiting for reconciliation")
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isAlertReady(ctx, kubeClient, namespacedName, &alert)); err != nil {
+	waitErr := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		isAlertReady(ctx, kubeClient, namespacedName, &alert))
+	if err := printReconcileResult(alertType.kind, namespacedName, "", "", alert.Status.Conditions, start); err != nil {
 		return err
 	}
+	if waitErr != nil {
+		return waitErr
+	}
 	logger.Successf("Alert reconciliation completed")
 	return nil
 }
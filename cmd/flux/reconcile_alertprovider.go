@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/fluxcd/flux2/internal/utils"
-	"github.com/fluxcd/pkg/apis/meta"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/types"
@@ -32,11 +31,15 @@ import (
 )
 
 var reconcileAlertProviderCmd = &cobra.Command{
-	Use:   "alert-provider [name]",
-	Short: "Reconcile a Provider",
-	Long:  `The reconcile alert-provider command triggers a reconciliation of a Provider resource and waits for it to finish.`,
+	Use:     "alert-provider [name]",
+	Aliases: []string{"provider"},
+	Short:   "Reconcile a Provider",
+	Long:    `The reconcile alert-provider command triggers a reconciliation of a Provider resource and waits for it to finish.`,
 	Example: `  # Trigger a reconciliation for an existing provider
   flux reconcile alert-provider slack
+
+  # Refresh a provider after its secret has changed
+  flux reconcile provider slack
 `,
 	RunE: reconcileAlertProviderCmdRun,
 }
@@ -51,6 +54,11 @@ func reconcileAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
+	if err := validateReconcileFlags(); err != nil {
+		return err
+	}
+	start := time.Now()
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -73,10 +81,10 @@ func reconcileAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 
 	if alertProvider.Annotations == nil {
 		alertProvider.Annotations = map[string]string{
-			meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+			reconcileArgs.annotationKey: time.Now().Format(time.RFC3339Nano),
 		}
 	} else {
-		alertProvider.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+		alertProvider.Annotations[reconcileArgs.annotationKey] = time.Now().Format(time.RFC3339Nano)
 	}
 	if err := kubeClient.Update(ctx, &alertProvider); err != nil {
 		return err
@@ -84,10 +92,14 @@ func reconcileAlertProviderCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("Provider annotated")
 
 	logger.Waitingf("waiting for reconciliation")
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isAlertProviderReady(ctx, kubeClient, namespacedName, &alertProvider)); err != nil {
+	waitErr := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		isAlertProviderReady(ctx, kubeClient, namespacedName, &alertProvider))
+	if err := printReconcileResult(alertProviderType.kind, namespacedName, "", "", alertProvider.Status.Conditions, start); err != nil {
 		return err
 	}
+	if waitErr != nil {
+		return waitErr
+	}
 	logger.Successf("Provider reconciliation completed")
 	return nil
 }
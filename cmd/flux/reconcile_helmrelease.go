@@ -41,24 +41,42 @@ var reconcileHrCmd = &cobra.Command{
 	Aliases: []string{"hr"},
 	Short:   "Reconcile a HelmRelease resource",
 	Long: `
-The reconcile kustomization command triggers a reconciliation of a HelmRelease resource and waits for it to finish.`,
+The reconcile kustomization command triggers a reconciliation of a HelmRelease resource and waits for it to finish.
+
+With --install-if-missing, a HelmRelease that has never been installed has its
+install failures and its Released and Remediated conditions cleared before the
+reconciliation, so that the controller attempts the initial install again, and
+the command fails if no release was installed. This only concerns the install,
+unlike spec.upgrade.force which makes the controller replace resources on upgrades.`,
 	Example: `  # Trigger a HelmRelease apply outside of the reconciliation interval
   flux reconcile hr podinfo
 
   # Trigger a reconciliation of the HelmRelease's source and apply changes
   flux reconcile hr podinfo --with-source
+
+  # Reset the install and upgrade failure counts of a HelmRelease that exhausted its retries, then retry
+  flux reconcile hr podinfo --reset
+
+  # Retry the initial install of a HelmRelease that was never installed, and verify that it was installed
+  flux reconcile hr podinfo --install-if-missing
 `,
 	RunE: reconcileHrCmdRun,
 }
 
 type reconcileHelmReleaseFlags struct {
 	syncHrWithSource bool
+	reset            bool
+	installIfMissing bool
 }
 
 var rhrArgs reconcileHelmReleaseFlags
 
 func init() {
 	reconcileHrCmd.Flags().BoolVar(&rhrArgs.syncHrWithSource, "with-source", false, "reconcile HelmRelease source")
+	reconcileHrCmd.Flags().BoolVar(&rhrArgs.reset, "reset", false,
+		"reset the install and upgrade failure counts of the HelmRelease before reconciling, so that it is retried after its retries were exhausted")
+	reconcileHrCmd.Flags().BoolVar(&rhrArgs.installIfMissing, "install-if-missing", false,
+		"if the HelmRelease has never been installed, clear its install failures and conditions before reconciling, and fail if it is still not installed")
 
 	reconcileCmd.AddCommand(reconcileHrCmd)
 }
@@ -69,6 +87,11 @@ func reconcileHrCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
+	if err := validateReconcileFlags(); err != nil {
+		return err
+	}
+	start := time.Now()
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -120,7 +143,30 @@ func reconcileHrCmdRun(cmd *cobra.Command, args []string) error {
 		rootArgs.namespace = nsCopy
 	}
 
+	if rhrArgs.reset {
+		logger.Actionf("resetting the failure counts of HelmRelease %s in %s namespace", name, rootArgs.namespace)
+		if err := resetHelmReleaseFailures(ctx, kubeClient, namespacedName, &helmRelease); err != nil {
+			return err
+		}
+		logger.Successf("HelmRelease failure counts reset")
+	}
+
+	installing := false
+	if rhrArgs.installIfMissing {
+		if !helmReleaseNeverInstalled(&helmRelease) {
+			logger.Successf("HelmRelease is installed at release revision %d", helmRelease.Status.LastReleaseRevision)
+		} else {
+			installing = true
+			logger.Actionf("clearing the install state of HelmRelease %s in %s namespace", name, rootArgs.namespace)
+			if err := clearHelmReleaseInstallState(ctx, kubeClient, namespacedName, &helmRelease); err != nil {
+				return err
+			}
+			logger.Successf("HelmRelease install state cleared")
+		}
+	}
+
 	lastHandledReconcileAt := helmRelease.Status.LastHandledReconcileAt
+	oldRevision := helmRelease.Status.LastAppliedRevision
 	logger.Actionf("annotating HelmRelease %s in %s namespace", name, rootArgs.namespace)
 	if err := requestHelmReleaseReconciliation(ctx, kubeClient, namespacedName, &helmRelease); err != nil {
 		return err
@@ -128,16 +174,29 @@ func reconcileHrCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("HelmRelease annotated")
 
 	logger.Waitingf("waiting for HelmRelease reconciliation")
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+	waitErr := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
 		helmReleaseReconciliationHandled(ctx, kubeClient, namespacedName, &helmRelease, lastHandledReconcileAt),
-	); err != nil {
+	)
+	if waitErr == nil {
+		err = kubeClient.Get(ctx, namespacedName, &helmRelease)
+		if err != nil {
+			return err
+		}
+	}
+	if err := printReconcileResult(helmv2.HelmReleaseKind, namespacedName, oldRevision,
+		helmRelease.Status.LastAppliedRevision, helmRelease.Status.Conditions, start); err != nil {
 		return err
 	}
+	if waitErr != nil {
+		return waitErr
+	}
 	logger.Successf("HelmRelease reconciliation completed")
-
-	err = kubeClient.Get(ctx, namespacedName, &helmRelease)
-	if err != nil {
-		return err
+	if installing {
+		if helmReleaseNeverInstalled(&helmRelease) {
+			_, message := statusAndMessage(helmRelease.Status.Conditions)
+			return fmt.Errorf("HelmRelease was not installed: %s", message)
+		}
+		logger.Successf("HelmRelease installed at release revision %d", helmRelease.Status.LastReleaseRevision)
 	}
 	if c := apimeta.FindStatusCondition(helmRelease.Status.Conditions, meta.ReadyCondition); c != nil {
 		switch c.Status {
@@ -161,6 +220,56 @@ func helmReleaseReconciliationHandled(ctx context.Context, kubeClient client.Cli
 	}
 }
 
+// resetHelmReleaseFailures sets the failure counts in the status of the
+// HelmRelease to zero. The controller stops retrying a release once
+// the counts exceed the retries of its remediation strategy.
+func resetHelmReleaseFailures(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, helmRelease *helmv2.HelmRelease) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if err := kubeClient.Get(ctx, namespacedName, helmRelease); err != nil {
+			return err
+		}
+		resetFailureCounts(helmRelease)
+		return kubeClient.Status().Update(ctx, helmRelease)
+	})
+}
+
+// resetFailureCounts sets the failure counts of the HelmRelease to zero.
+func resetFailureCounts(helmRelease *helmv2.HelmRelease) {
+	helmRelease.Status.Failures = 0
+	helmRelease.Status.InstallFailures = 0
+	helmRelease.Status.UpgradeFailures = 0
+}
+
+// clearHelmReleaseInstallState resets the install failure counts of the
+// HelmRelease and removes the conditions set by the failed install
+// attempts, so the controller doesn't consider its retries exhausted.
+func clearHelmReleaseInstallState(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, helmRelease *helmv2.HelmRelease) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if err := kubeClient.Get(ctx, namespacedName, helmRelease); err != nil {
+			return err
+		}
+		clearInstallState(helmRelease)
+		return kubeClient.Status().Update(ctx, helmRelease)
+	})
+}
+
+// helmReleaseNeverInstalled returns true if the HelmRelease has no
+// release revision, the controller has never installed it.
+func helmReleaseNeverInstalled(helmRelease *helmv2.HelmRelease) bool {
+	return helmRelease.Status.LastReleaseRevision == 0
+}
+
+// clearInstallState resets the install failure counts of the
+// HelmRelease and removes its Released and Remediated conditions.
+func clearInstallState(helmRelease *helmv2.HelmRelease) {
+	helmRelease.Status.Failures = 0
+	helmRelease.Status.InstallFailures = 0
+	apimeta.RemoveStatusCondition(&helmRelease.Status.Conditions, helmv2.ReleasedCondition)
+	apimeta.RemoveStatusCondition(&helmRelease.Status.Conditions, helmv2.RemediatedCondition)
+}
+
 func requestHelmReleaseReconciliation(ctx context.Context, kubeClient client.Client,
 	namespacedName types.NamespacedName, helmRelease *helmv2.HelmRelease) error {
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
@@ -169,10 +278,10 @@ func requestHelmReleaseReconciliation(ctx context.Context, kubeClient client.Cli
 		}
 		if helmRelease.Annotations == nil {
 			helmRelease.Annotations = map[string]string{
-				meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+				reconcileArgs.annotationKey: time.Now().Format(time.RFC3339Nano),
 			}
 		} else {
-			helmRelease.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+			helmRelease.Annotations[reconcileArgs.annotationKey] = time.Now().Format(time.RFC3339Nano)
 		}
 		return kubeClient.Update(ctx, helmRelease)
 	})
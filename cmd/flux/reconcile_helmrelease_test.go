@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	helmv2 "github.com/fluxcd/helm-controller/api/v2beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResetFailureCounts(t *testing.T) {
+	helmRelease := &helmv2.HelmRelease{
+		Status: helmv2.HelmReleaseStatus{
+			Failures:            5,
+			InstallFailures:     3,
+			UpgradeFailures:     2,
+			LastReleaseRevision: 4,
+		},
+	}
+	resetFailureCounts(helmRelease)
+	if f := helmRelease.Status.Failures; f != 0 {
+		t.Errorf("Failures = %d, expect 0", f)
+	}
+	if f := helmRelease.Status.InstallFailures; f != 0 {
+		t.Errorf("InstallFailures = %d, expect 0", f)
+	}
+	if f := helmRelease.Status.UpgradeFailures; f != 0 {
+		t.Errorf("UpgradeFailures = %d, expect 0", f)
+	}
+	if r := helmRelease.Status.LastReleaseRevision; r != 4 {
+		t.Errorf("LastReleaseRevision = %d, expect 4", r)
+	}
+}
+
+func TestHelmReleaseNeverInstalled(t *testing.T) {
+	tests := []struct {
+		name     string
+		revision int
+		expect   bool
+	}{
+		{"never installed", 0, true},
+		{"installed", 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmRelease := &helmv2.HelmRelease{
+				Status: helmv2.HelmReleaseStatus{LastReleaseRevision: tt.revision},
+			}
+			if got := helmReleaseNeverInstalled(helmRelease); got != tt.expect {
+				t.Errorf("helmReleaseNeverInstalled() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestClearInstallState(t *testing.T) {
+	helmRelease := &helmv2.HelmRelease{
+		Status: helmv2.HelmReleaseStatus{
+			Failures:        4,
+			InstallFailures: 4,
+			UpgradeFailures: 1,
+			Conditions: []metav1.Condition{
+				{Type: meta.ReadyCondition, Status: metav1.ConditionFalse, Reason: "InstallFailed"},
+				{Type: helmv2.ReleasedCondition, Status: metav1.ConditionFalse, Reason: "InstallFailed"},
+				{Type: helmv2.RemediatedCondition, Status: metav1.ConditionTrue, Reason: "UninstallSucceeded"},
+			},
+		},
+	}
+	clearInstallState(helmRelease)
+	if f := helmRelease.Status.Failures; f != 0 {
+		t.Errorf("Failures = %d, expect 0", f)
+	}
+	if f := helmRelease.Status.InstallFailures; f != 0 {
+		t.Errorf("InstallFailures = %d, expect 0", f)
+	}
+	if f := helmRelease.Status.UpgradeFailures; f != 1 {
+		t.Errorf("UpgradeFailures = %d, expect 1", f)
+	}
+	for _, condition := range []string{helmv2.ReleasedCondition, helmv2.RemediatedCondition} {
+		if apimeta.FindStatusCondition(helmRelease.Status.Conditions, condition) != nil {
+			t.Errorf("%s condition was not removed", condition)
+		}
+	}
+	if apimeta.FindStatusCondition(helmRelease.Status.Conditions, meta.ReadyCondition) == nil {
+		t.Errorf("%s condition was removed", meta.ReadyCondition)
+	}
+}
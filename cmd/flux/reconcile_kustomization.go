@@ -19,6 +19,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
@@ -30,6 +34,10 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/k8sdeps/kunstruct"
+	"sigs.k8s.io/kustomize/api/krusty"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
@@ -46,18 +54,58 @@ The reconcile kustomization command triggers a reconciliation of a Kustomization
 
   # Trigger a sync of the Kustomization's source and apply changes
   flux reconcile kustomization podinfo --with-source
+
+  # Trigger a Kustomization apply, then reconcile the Kustomizations that depend on it
+  flux reconcile kustomization infrastructure --cascade
+
+  # Trigger a Kustomization apply and print the result as JSON
+  flux reconcile kustomization podinfo --output json
+
+  # Compare a local overlay with the objects applied by the Kustomization before reconciling
+  flux reconcile kustomization podinfo --from-file ./deploy/podinfo
+
+  # Apply the latest revision once, then suspend the Kustomization
+  flux reconcile kustomization podinfo --with-source --pause-after
+
+  # Fetch the source, verify that its artifact is at the given commit, then apply it
+  flux reconcile kustomization podinfo --with-source --revision 8f3b1a2
+
+  # Trigger a Kustomization apply and wait for its health check targets to be healthy
+  flux reconcile kustomization podinfo --wait-for-health
+
+  # Trigger a Kustomization apply without knowing its namespace, the name must be unique in the cluster
+  flux reconcile kustomization podinfo --context-from-kustomization
 `,
 	RunE: reconcileKsCmdRun,
 }
 
 type reconcileKsFlags struct {
 	syncKsWithSource bool
+	fromFile         string
+	cascade          bool
+	pauseAfter       bool
+	revision         string
+	waitForHealth    bool
+	findNamespace    bool
 }
 
 var rksArgs reconcileKsFlags
 
 func init() {
 	reconcileKsCmd.Flags().BoolVar(&rksArgs.syncKsWithSource, "with-source", false, "reconcile Kustomization source")
+	reconcileKsCmd.Flags().BoolVar(&rksArgs.cascade, "cascade", false,
+		"after reconciling the Kustomization, reconcile the Kustomizations that depend on it in dependency order")
+	reconcileKsCmd.Flags().StringVar(&rksArgs.fromFile, "from-file", "",
+		"path to a local manifest file or kustomize overlay, warn about differences with the objects applied by the Kustomization before reconciling")
+	reconcileKsCmd.Flags().StringVar(&rksArgs.revision, "revision", "",
+		"verify that the source artifact is at the given revision, e.g. a commit SHA or 'main/<sha>', before reconciling")
+	reconcileKsCmd.Flags().BoolVar(&rksArgs.pauseAfter, "pause-after", false,
+		"suspend the Kustomization once it has been reconciled successfully, so that the applied revision is kept")
+	reconcileKsCmd.Flags().BoolVar(&rksArgs.waitForHealth, "wait-for-health", false,
+		"after the Kustomization is ready, wait for the targets of its health checks to be healthy")
+	reconcileKsCmd.Flags().BoolVar(&rksArgs.findNamespace, "context-from-kustomization", false,
+		"search all namespaces for the Kustomization with the given name and reconcile it in its namespace, "+
+			"ignored when --namespace is set")
 
 	reconcileCmd.AddCommand(reconcileKsCmd)
 }
@@ -68,6 +116,11 @@ func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
+	if err := validateReconcileFlags(); err != nil {
+		return err
+	}
+	start := time.Now()
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -76,6 +129,14 @@ func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if rksArgs.findNamespace && !cmd.Flags().Changed("namespace") {
+		namespace, err := findKustomizationNamespace(ctx, kubeClient, name)
+		if err != nil {
+			return err
+		}
+		rootArgs.namespace = namespace
+	}
+
 	namespacedName := types.NamespacedName{
 		Namespace: rootArgs.namespace,
 		Name:      name,
@@ -90,6 +151,13 @@ func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resource is suspended")
 	}
 
+	if rksArgs.fromFile != "" {
+		logger.Actionf("comparing %s with the objects applied by Kustomization %s", rksArgs.fromFile, name)
+		if err := compareKsSnapshot(kustomization, rksArgs.fromFile); err != nil {
+			return err
+		}
+	}
+
 	if rksArgs.syncKsWithSource {
 		nsCopy := rootArgs.namespace
 		if kustomization.Spec.SourceRef.Namespace != "" {
@@ -113,7 +181,14 @@ func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
 		rootArgs.namespace = nsCopy
 	}
 
+	if rksArgs.revision != "" {
+		if err := verifyKsSourceRevision(ctx, kubeClient, kustomization, rksArgs.revision); err != nil {
+			return err
+		}
+	}
+
 	lastHandledReconcileAt := kustomization.Status.LastHandledReconcileAt
+	oldRevision := kustomization.Status.LastAppliedRevision
 	logger.Actionf("annotating Kustomization %s in %s namespace", name, rootArgs.namespace)
 	if err := requestKustomizeReconciliation(ctx, kubeClient, namespacedName, &kustomization); err != nil {
 		return err
@@ -121,21 +196,232 @@ func reconcileKsCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("Kustomization annotated")
 
 	logger.Waitingf("waiting for Kustomization reconciliation")
-	if err := wait.PollImmediate(
+	waitErr := wait.PollImmediate(
 		rootArgs.pollInterval, rootArgs.timeout,
 		kustomizeReconciliationHandled(ctx, kubeClient, namespacedName, &kustomization, lastHandledReconcileAt),
-	); err != nil {
+	)
+	if err := printReconcileResult(kustomizev1.KustomizationKind, namespacedName, oldRevision,
+		kustomization.Status.LastAppliedRevision, kustomization.Status.Conditions, start); err != nil {
 		return err
 	}
+	if waitErr != nil {
+		return waitErr
+	}
 	logger.Successf("Kustomization reconciliation completed")
 
 	if apimeta.IsStatusConditionFalse(kustomization.Status.Conditions, meta.ReadyCondition) {
 		return fmt.Errorf("Kustomization reconciliation failed")
 	}
 	logger.Successf("reconciled revision %s", kustomization.Status.LastAppliedRevision)
+	if rksArgs.revision != "" && !revisionMatches(kustomization.Status.LastAppliedRevision, rksArgs.revision) {
+		return fmt.Errorf("Kustomization applied revision %s instead of %s, the source has moved on",
+			kustomization.Status.LastAppliedRevision, rksArgs.revision)
+	}
+
+	if rksArgs.waitForHealth {
+		if err := waitForKsHealthChecks(ctx, kubeClient, kustomization); err != nil {
+			return err
+		}
+	}
+
+	if rksArgs.pauseAfter {
+		logger.Actionf("suspending Kustomization %s in %s namespace", name, rootArgs.namespace)
+		if err := suspendKustomization(ctx, kubeClient, namespacedName, &kustomization); err != nil {
+			return err
+		}
+		logger.Successf("Kustomization suspended at revision %s", kustomization.Status.LastAppliedRevision)
+	}
+
+	if rksArgs.cascade {
+		return reconcileKsDependents(ctx, kubeClient, namespacedName)
+	}
 	return nil
 }
 
+// findKustomizationNamespace returns the namespace of the only
+// Kustomization with the given name in the cluster.
+func findKustomizationNamespace(ctx context.Context, kubeClient client.Client, name string) (string, error) {
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return "", err
+	}
+
+	var namespaces []string
+	for _, ks := range list.Items {
+		if ks.Name == name {
+			namespaces = append(namespaces, ks.Namespace)
+		}
+	}
+	switch len(namespaces) {
+	case 0:
+		return "", fmt.Errorf("no Kustomization named '%s' found in any namespace", name)
+	case 1:
+		logger.Successf("found Kustomization %s in %s namespace", name, namespaces[0])
+		return namespaces[0], nil
+	default:
+		sort.Strings(namespaces)
+		return "", fmt.Errorf("Kustomization name '%s' is ambiguous, it exists in the namespaces %s, use --namespace to select one",
+			name, strings.Join(namespaces, ", "))
+	}
+}
+
+// waitForKsHealthChecks polls the targets of the health checks of the
+// Kustomization until all of them are healthy. On timeout, the targets
+// that are still unhealthy are reported.
+func waitForKsHealthChecks(ctx context.Context, kubeClient client.Client, kustomization kustomizev1.Kustomization) error {
+	if len(kustomization.Spec.HealthChecks) == 0 {
+		logger.Successf("Kustomization has no health checks")
+		return nil
+	}
+
+	logger.Waitingf("waiting for %d health check targets", len(kustomization.Spec.HealthChecks))
+	var unhealthy []ksHealthCheck
+	err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout, func() (bool, error) {
+		unhealthy = nil
+		for _, ref := range kustomization.Spec.HealthChecks {
+			if check := lookupHealthCheck(ctx, kubeClient, ref); check.Status != status.CurrentStatus.String() {
+				unhealthy = append(unhealthy, check)
+			}
+		}
+		return len(unhealthy) == 0, nil
+	})
+	if err != nil {
+		for _, check := range unhealthy {
+			target := check.Name
+			if check.Namespace != "" {
+				target = fmt.Sprintf("%s/%s", check.Namespace, check.Name)
+			}
+			logger.Failuref("%s %s: %s %s", check.Kind, target, check.Status, check.Message)
+		}
+		return fmt.Errorf("%d health check targets are not healthy: %w", len(unhealthy), err)
+	}
+	logger.Successf("all health check targets are healthy")
+	return nil
+}
+
+// reconcileKsDependents reconciles the Kustomizations depending,
+// directly or transitively, on the given Kustomization, so that each
+// one is reconciled after the Kustomizations it depends on.
+func reconcileKsDependents(ctx context.Context, kubeClient client.Client, root types.NamespacedName) error {
+	var list kustomizev1.KustomizationList
+	if err := kubeClient.List(ctx, &list); err != nil {
+		return err
+	}
+
+	suspended := map[types.NamespacedName]bool{}
+	for _, ks := range list.Items {
+		suspended[types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name}] = ks.Spec.Suspend
+	}
+	ordered, err := orderKsDependents(root, list.Items)
+	if err != nil {
+		return err
+	}
+	if len(ordered) == 0 {
+		logger.Successf("no Kustomizations depend on %s", root)
+		return nil
+	}
+
+	var names []string
+	for _, name := range ordered {
+		names = append(names, name.String())
+	}
+	logger.Actionf("reconciling dependent Kustomizations in order: %s", strings.Join(names, ", "))
+
+	for _, name := range ordered {
+		if suspended[name] {
+			logger.Warningf("skipping suspended Kustomization %s", name)
+			continue
+		}
+
+		var kustomization kustomizev1.Kustomization
+		if err := kubeClient.Get(ctx, name, &kustomization); err != nil {
+			return err
+		}
+		lastHandledReconcileAt := kustomization.Status.LastHandledReconcileAt
+		if err := requestKustomizeReconciliation(ctx, kubeClient, name, &kustomization); err != nil {
+			return err
+		}
+		logger.Waitingf("waiting for Kustomization %s reconciliation", name)
+		if err := wait.PollImmediate(
+			rootArgs.pollInterval, rootArgs.timeout,
+			kustomizeReconciliationHandled(ctx, kubeClient, name, &kustomization, lastHandledReconcileAt),
+		); err != nil {
+			return err
+		}
+		if apimeta.IsStatusConditionFalse(kustomization.Status.Conditions, meta.ReadyCondition) {
+			return fmt.Errorf("Kustomization %s reconciliation failed", name)
+		}
+		logger.Successf("Kustomization %s reconciled revision %s", name, kustomization.Status.LastAppliedRevision)
+	}
+	return nil
+}
+
+// orderKsDependents returns the Kustomizations depending, directly or
+// transitively, on root, ordered so that each one comes after the
+// Kustomizations it depends on. Kustomizations that are ready at the
+// same time are sorted by name.
+func orderKsDependents(root types.NamespacedName, kustomizations []kustomizev1.Kustomization) ([]types.NamespacedName, error) {
+	// dependents maps each Kustomization to the ones depending on it
+	dependents := map[types.NamespacedName][]types.NamespacedName{}
+	for _, ks := range kustomizations {
+		name := types.NamespacedName{Namespace: ks.Namespace, Name: ks.Name}
+		for _, dep := range ks.Spec.DependsOn {
+			depName := types.NamespacedName{Namespace: dep.Namespace, Name: dep.Name}
+			if depName.Namespace == "" {
+				depName.Namespace = ks.Namespace
+			}
+			dependents[depName] = append(dependents[depName], name)
+		}
+	}
+
+	selected := map[types.NamespacedName]bool{}
+	queue := []types.NamespacedName{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			if !selected[dependent] {
+				selected[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	if selected[root] {
+		return nil, fmt.Errorf("dependency cycle detected, Kustomization %s depends on itself", root)
+	}
+	// order the dependents so that each one comes after its dependencies
+	inDegree := map[types.NamespacedName]int{}
+	for name := range selected {
+		inDegree[name] = 0
+	}
+	for name := range selected {
+		for _, dependent := range dependents[name] {
+			inDegree[dependent]++
+		}
+	}
+	var ordered []types.NamespacedName
+	for len(ordered) < len(selected) {
+		var ready []types.NamespacedName
+		for name, degree := range inDegree {
+			if degree == 0 {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			return nil, fmt.Errorf("dependency cycle detected between the Kustomizations depending on %s", root)
+		}
+		sort.Slice(ready, func(i, j int) bool { return ready[i].String() < ready[j].String() })
+		for _, name := range ready {
+			delete(inDegree, name)
+			for _, dependent := range dependents[name] {
+				inDegree[dependent]--
+			}
+		}
+		ordered = append(ordered, ready...)
+	}
+	return ordered, nil
+}
+
 func kustomizeReconciliationHandled(ctx context.Context, kubeClient client.Client,
 	namespacedName types.NamespacedName, kustomization *kustomizev1.Kustomization, lastHandledReconcileAt string) wait.ConditionFunc {
 	return func() (bool, error) {
@@ -155,11 +441,174 @@ func requestKustomizeReconciliation(ctx context.Context, kubeClient client.Clien
 		}
 		if kustomization.Annotations == nil {
 			kustomization.Annotations = map[string]string{
-				meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+				reconcileArgs.annotationKey: time.Now().Format(time.RFC3339Nano),
 			}
 		} else {
-			kustomization.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+			kustomization.Annotations[reconcileArgs.annotationKey] = time.Now().Format(time.RFC3339Nano)
+		}
+		return kubeClient.Update(ctx, kustomization)
+	})
+}
+
+// verifyKsSourceRevision returns an error if the artifact of the
+// Kustomization's source is not at the given revision. The controller
+// always applies the latest artifact of the source, so the revision
+// can only be checked, not requested.
+func verifyKsSourceRevision(ctx context.Context, kubeClient client.Client, kustomization kustomizev1.Kustomization, revision string) error {
+	namespacedName := types.NamespacedName{
+		Namespace: kustomization.Namespace,
+		Name:      kustomization.Spec.SourceRef.Name,
+	}
+	if kustomization.Spec.SourceRef.Namespace != "" {
+		namespacedName.Namespace = kustomization.Spec.SourceRef.Namespace
+	}
+
+	var artifact *sourcev1.Artifact
+	switch kustomization.Spec.SourceRef.Kind {
+	case sourcev1.GitRepositoryKind:
+		var repository sourcev1.GitRepository
+		if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+			return err
+		}
+		artifact = repository.GetArtifact()
+	case sourcev1.BucketKind:
+		var bucket sourcev1.Bucket
+		if err := kubeClient.Get(ctx, namespacedName, &bucket); err != nil {
+			return err
+		}
+		artifact = bucket.GetArtifact()
+	default:
+		return fmt.Errorf("source kind '%s' is not supported", kustomization.Spec.SourceRef.Kind)
+	}
+
+	source := fmt.Sprintf("%s %s/%s", kustomization.Spec.SourceRef.Kind, namespacedName.Namespace, namespacedName.Name)
+	if artifact == nil {
+		return fmt.Errorf("revision %s is not available, %s has no artifact", revision, source)
+	}
+	if !revisionMatches(artifact.Revision, revision) {
+		return fmt.Errorf("revision %s is not available, the artifact of %s is at revision %s", revision, source, artifact.Revision)
+	}
+	logger.Successf("%s is at revision %s", source, artifact.Revision)
+	return nil
+}
+
+// revisionMatches returns true if the artifact revision, in the
+// '<branch>/<sha>' format of Git sources or the checksum of Buckets,
+// is the given revision, or ends with the given commit SHA or prefix.
+func revisionMatches(artifactRevision, revision string) bool {
+	if artifactRevision == revision || strings.HasSuffix(artifactRevision, "/"+revision) {
+		return true
+	}
+	sha := artifactRevision[strings.LastIndex(artifactRevision, "/")+1:]
+	return len(revision) >= 7 && strings.HasPrefix(sha, revision)
+}
+
+// suspendKustomization sets spec.suspend on the Kustomization,
+// retrying on conflicts with the status updates of the controller.
+func suspendKustomization(ctx context.Context, kubeClient client.Client,
+	namespacedName types.NamespacedName, kustomization *kustomizev1.Kustomization) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		if err := kubeClient.Get(ctx, namespacedName, kustomization); err != nil {
+			return err
 		}
+		kustomization.Spec.Suspend = true
 		return kubeClient.Update(ctx, kustomization)
 	})
 }
+
+// compareKsSnapshot warns about the object kinds and namespaces that
+// differ between the manifests found at path and the snapshot of the
+// objects last applied by the Kustomization.
+func compareKsSnapshot(kustomization kustomizev1.Kustomization, path string) error {
+	local, err := localSnapshotEntries(path, kustomization.Spec.TargetNamespace)
+	if err != nil {
+		return err
+	}
+
+	if kustomization.Status.Snapshot == nil {
+		logger.Warningf("Kustomization has not applied any objects yet")
+		return nil
+	}
+	applied := map[string]bool{}
+	for _, entry := range kustomization.Status.Snapshot.Entries {
+		for _, kind := range entry.Kinds {
+			applied[snapshotKey(entry.Namespace, kind)] = true
+		}
+	}
+
+	diffs := ksSnapshotDiffs(local, applied, path)
+	if len(diffs) == 0 {
+		logger.Successf("%s matches the applied objects", path)
+		return nil
+	}
+	for _, diff := range diffs {
+		logger.Warningf("%s", diff)
+	}
+	return nil
+}
+
+// ksSnapshotDiffs returns the sorted differences between the snapshot
+// keys of the manifests found at path and of the applied objects.
+func ksSnapshotDiffs(local, applied map[string]bool, path string) []string {
+	var diffs []string
+	for key := range local {
+		if !applied[key] {
+			diffs = append(diffs, fmt.Sprintf("%s is not applied by the Kustomization", key))
+		}
+	}
+	for key := range applied {
+		if !local[key] {
+			diffs = append(diffs, fmt.Sprintf("%s is applied by the Kustomization but not found in %s", key, path))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// localSnapshotEntries returns the namespace and kind of the objects
+// in the manifest file at path, or built from the kustomize overlay
+// if path is a directory. Objects without a namespace are placed in
+// the target namespace.
+func localSnapshotEntries(path, targetNamespace string) (map[string]bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []byte
+	if info.IsDir() {
+		k := krusty.MakeKustomizer(filesys.MakeFsOnDisk(), krusty.MakeDefaultOptions())
+		resMap, err := k.Run(path)
+		if err != nil {
+			return nil, fmt.Errorf("kustomize build failed: %w", err)
+		}
+		if manifests, err = resMap.AsYaml(); err != nil {
+			return nil, err
+		}
+	} else {
+		if manifests, err = ioutil.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	objects, err := kunstruct.NewKunstructuredFactoryImpl().SliceFromBytes(manifests)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s failed: %w", path, err)
+	}
+	entries := map[string]bool{}
+	for _, object := range objects {
+		namespace := object.GetNamespace()
+		if namespace == "" {
+			namespace = targetNamespace
+		}
+		entries[snapshotKey(namespace, object.GetKind())] = true
+	}
+	return entries, nil
+}
+
+func snapshotKey(namespace, kind string) string {
+	if namespace == "" {
+		return kind
+	}
+	return fmt.Sprintf("%s/%s", namespace, kind)
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/fluxcd/flux2/internal/utils"
+)
+
+func testKustomization(namespace, name string, dependsOn ...string) kustomizev1.Kustomization {
+	return kustomizev1.Kustomization{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: kustomizev1.KustomizationSpec{
+			DependsOn: utils.MakeDependsOn(dependsOn),
+		},
+	}
+}
+
+func TestOrderKsDependents(t *testing.T) {
+	root := types.NamespacedName{Namespace: "flux-system", Name: "infra"}
+	name := func(namespace, name string) types.NamespacedName {
+		return types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
+	tests := []struct {
+		name           string
+		kustomizations []kustomizev1.Kustomization
+		expect         []types.NamespacedName
+		expectErr      bool
+	}{
+		{
+			name: "no dependents",
+			kustomizations: []kustomizev1.Kustomization{
+				testKustomization("flux-system", "infra"),
+				testKustomization("flux-system", "apps"),
+			},
+			expect: nil,
+		},
+		{
+			name: "chain",
+			kustomizations: []kustomizev1.Kustomization{
+				testKustomization("flux-system", "infra"),
+				testKustomization("flux-system", "apps", "config"),
+				testKustomization("flux-system", "config", "infra"),
+			},
+			expect: []types.NamespacedName{name("flux-system", "config"), name("flux-system", "apps")},
+		},
+		{
+			name: "diamond sorted by name",
+			kustomizations: []kustomizev1.Kustomization{
+				testKustomization("flux-system", "infra"),
+				testKustomization("flux-system", "monitoring", "infra"),
+				testKustomization("flux-system", "config", "infra"),
+				testKustomization("flux-system", "apps", "config", "monitoring"),
+			},
+			expect: []types.NamespacedName{
+				name("flux-system", "config"),
+				name("flux-system", "monitoring"),
+				name("flux-system", "apps"),
+			},
+		},
+		{
+			name: "dependencies outside the cascade don't block",
+			kustomizations: []kustomizev1.Kustomization{
+				testKustomization("flux-system", "infra"),
+				testKustomization("flux-system", "crds"),
+				testKustomization("flux-system", "apps", "infra", "crds"),
+			},
+			expect: []types.NamespacedName{name("flux-system", "apps")},
+		},
+		{
+			name: "cross namespace",
+			kustomizations: []kustomizev1.Kustomization{
+				testKustomization("flux-system", "infra"),
+				testKustomization("team-a", "apps", "flux-system/infra"),
+				testKustomization("team-b", "apps", "infra"),
+			},
+			expect: []types.NamespacedName{name("team-a", "apps")},
+		},
+		{
+			name: "cycle through the root",
+			kustomizations: []kustomizev1.Kustomization{
+				testKustomization("flux-system", "infra", "apps"),
+				testKustomization("flux-system", "apps", "infra"),
+			},
+			expectErr: true,
+		},
+		{
+			name: "cycle between the dependents",
+			kustomizations: []kustomizev1.Kustomization{
+				testKustomization("flux-system", "infra"),
+				testKustomization("flux-system", "apps", "infra", "config"),
+				testKustomization("flux-system", "config", "apps"),
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := orderKsDependents(root, tt.kustomizations)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("orderKsDependents() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("orderKsDependents() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRevisionMatches(t *testing.T) {
+	tests := []struct {
+		name             string
+		artifactRevision string
+		revision         string
+		expect           bool
+	}{
+		{"same revision", "main/3f2a9c41e0b5d6a7", "main/3f2a9c41e0b5d6a7", true},
+		{"full sha", "main/3f2a9c41e0b5d6a7", "3f2a9c41e0b5d6a7", true},
+		{"sha prefix", "main/3f2a9c41e0b5d6a7", "3f2a9c4", true},
+		{"sha prefix too short", "main/3f2a9c41e0b5d6a7", "3f2a9c", false},
+		{"other sha", "main/3f2a9c41e0b5d6a7", "8e1b7d2", false},
+		{"other branch", "main/3f2a9c41e0b5d6a7", "dev/3f2a9c41e0b5d6a7", false},
+		{"bucket checksum", "a1b2c3d4e5f6", "a1b2c3d4e5f6", true},
+		{"bucket checksum prefix", "a1b2c3d4e5f6", "a1b2c3d4", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revisionMatches(tt.artifactRevision, tt.revision); got != tt.expect {
+				t.Errorf("revisionMatches(%q, %q) = %v, expect %v", tt.artifactRevision, tt.revision, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestKsSnapshotDiffs(t *testing.T) {
+	tests := []struct {
+		name    string
+		local   map[string]bool
+		applied map[string]bool
+		expect  []string
+	}{
+		{
+			name:    "matching",
+			local:   map[string]bool{"apps/Deployment": true, "Namespace": true},
+			applied: map[string]bool{"apps/Deployment": true, "Namespace": true},
+			expect:  nil,
+		},
+		{
+			name:    "differences",
+			local:   map[string]bool{"apps/Deployment": true, "apps/Service": true},
+			applied: map[string]bool{"apps/Deployment": true, "apps/ConfigMap": true},
+			expect: []string{
+				"apps/ConfigMap is applied by the Kustomization but not found in ./deploy",
+				"apps/Service is not applied by the Kustomization",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ksSnapshotDiffs(tt.local, tt.applied, "./deploy"); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("ksSnapshotDiffs() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestLocalSnapshotEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flux-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "manifests.yaml")
+	manifests := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: podinfo
+  namespace: apps
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: podinfo
+`
+	if err := ioutil.WriteFile(path, []byte(manifests), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := localSnapshotEntries(path, "default")
+	if err != nil {
+		t.Fatalf("localSnapshotEntries() error = %v", err)
+	}
+	expect := map[string]bool{
+		"apps/Deployment": true,
+		"default/Service": true,
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("localSnapshotEntries() = %v, expect %v", got, expect)
+	}
+}
@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/fluxcd/flux2/internal/utils"
-	"github.com/fluxcd/pkg/apis/meta"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/types"
@@ -51,6 +50,11 @@ func reconcileReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	}
 	name := args[0]
 
+	if err := validateReconcileFlags(); err != nil {
+		return err
+	}
+	start := time.Now()
+
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
@@ -77,10 +81,10 @@ func reconcileReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Actionf("annotating Receiver %s in %s namespace", name, rootArgs.namespace)
 	if receiver.Annotations == nil {
 		receiver.Annotations = map[string]string{
-			meta.ReconcileRequestAnnotation: time.Now().Format(time.RFC3339Nano),
+			reconcileArgs.annotationKey: time.Now().Format(time.RFC3339Nano),
 		}
 	} else {
-		receiver.Annotations[meta.ReconcileRequestAnnotation] = time.Now().Format(time.RFC3339Nano)
+		receiver.Annotations[reconcileArgs.annotationKey] = time.Now().Format(time.RFC3339Nano)
 	}
 	if err := kubeClient.Update(ctx, &receiver); err != nil {
 		return err
@@ -88,12 +92,14 @@ func reconcileReceiverCmdRun(cmd *cobra.Command, args []string) error {
 	logger.Successf("Receiver annotated")
 
 	logger.Waitingf("waiting for Receiver reconciliation")
-	if err := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
-		isReceiverReady(ctx, kubeClient, namespacedName, &receiver)); err != nil {
+	waitErr := wait.PollImmediate(rootArgs.pollInterval, rootArgs.timeout,
+		isReceiverReady(ctx, kubeClient, namespacedName, &receiver))
+	if err := printReconcileResult(receiverType.kind, namespacedName, "", "", receiver.Status.Conditions, start); err != nil {
 		return err
 	}
-
+	if waitErr != nil {
+		return waitErr
+	}
 	logger.Successf("Receiver reconciliation completed")
-
 	return nil
 }
@@ -79,3 +79,10 @@ func (obj bucketAdapter) lastHandledReconcileRequest() string {
 func (obj bucketAdapter) successMessage() string {
 	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
 }
+
+func (obj bucketAdapter) lastRevision() string {
+	if obj.Status.Artifact == nil {
+		return ""
+	}
+	return obj.Status.Artifact.Revision
+}
@@ -17,9 +17,16 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
+
 	sourcev1 "github.com/fluxcd/source-controller/api/v1beta1"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/flux2/internal/utils"
 )
 
 var reconcileSourceGitCmd = &cobra.Command{
@@ -28,17 +35,76 @@ var reconcileSourceGitCmd = &cobra.Command{
 	Long:  `The reconcile source command triggers a reconciliation of a GitRepository resource and waits for it to finish.`,
 	Example: `  # Trigger a git pull for an existing source
   flux reconcile source git podinfo
+
+  # Discard the current artifact and build a new one from a fresh clone
+  flux reconcile source git podinfo --force-clone
 `,
-	RunE: reconcileCommand{
-		apiType: gitRepositoryType,
-		object:  gitRepositoryAdapter{&sourcev1.GitRepository{}},
-	}.run,
+	RunE: reconcileSourceGitCmdRun,
 }
 
+type reconcileSourceGitFlags struct {
+	forceClone bool
+}
+
+var reconcileSourceGitArgs reconcileSourceGitFlags
+
 func init() {
+	reconcileSourceGitCmd.Flags().BoolVar(&reconcileSourceGitArgs.forceClone, "force-clone", false,
+		"clear the artifact from the GitRepository status before reconciling, so that the controller clones the repository "+
+			"and builds a new artifact even if the revision hasn't changed")
 	reconcileSourceCmd.AddCommand(reconcileSourceGitCmd)
 }
 
+func reconcileSourceGitCmdRun(cmd *cobra.Command, args []string) error {
+	if err := validateReconcileFlags(); err != nil {
+		return err
+	}
+
+	if reconcileSourceGitArgs.forceClone && len(args) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
+		defer cancel()
+
+		kubeClient, err := utils.KubeClient(rootArgs.kubeconfig, rootArgs.kubecontext)
+		if err != nil {
+			return err
+		}
+
+		namespacedName := types.NamespacedName{
+			Namespace: rootArgs.namespace,
+			Name:      args[0],
+		}
+		logger.Actionf("clearing the artifact of GitRepository %s in %s namespace", args[0], rootArgs.namespace)
+		if err := clearGitRepositoryArtifact(ctx, kubeClient, namespacedName); err != nil {
+			return err
+		}
+		logger.Warningf("consumers of the GitRepository can't fetch its artifact until the reconciliation completes")
+	}
+
+	return reconcileCommand{
+		apiType: gitRepositoryType,
+		object:  gitRepositoryAdapter{&sourcev1.GitRepository{}},
+	}.run(cmd, args)
+}
+
+// clearGitRepositoryArtifact removes the artifact from the status of
+// the GitRepository. The controller skips building an artifact when
+// the revision is unchanged, and has no annotation to discard it, so
+// clearing it makes the next reconciliation clone and package the
+// repository from scratch.
+func clearGitRepositoryArtifact(ctx context.Context, kubeClient client.Client, namespacedName types.NamespacedName) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() (err error) {
+		var repository sourcev1.GitRepository
+		if err := kubeClient.Get(ctx, namespacedName, &repository); err != nil {
+			return err
+		}
+		if repository.Spec.Suspend {
+			return fmt.Errorf("resource is suspended")
+		}
+		repository.Status.Artifact = nil
+		return kubeClient.Status().Update(ctx, &repository)
+	})
+}
+
 func (obj gitRepositoryAdapter) lastHandledReconcileRequest() string {
 	return obj.Status.GetLastHandledReconcileRequest()
 }
@@ -46,3 +112,10 @@ func (obj gitRepositoryAdapter) lastHandledReconcileRequest() string {
 func (obj gitRepositoryAdapter) successMessage() string {
 	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
 }
+
+func (obj gitRepositoryAdapter) lastRevision() string {
+	if obj.Status.Artifact == nil {
+		return ""
+	}
+	return obj.Status.Artifact.Revision
+}
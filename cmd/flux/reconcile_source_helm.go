@@ -46,3 +46,10 @@ func (obj helmRepositoryAdapter) lastHandledReconcileRequest() string {
 func (obj helmRepositoryAdapter) successMessage() string {
 	return fmt.Sprintf("fetched revision %s", obj.Status.Artifact.Revision)
 }
+
+func (obj helmRepositoryAdapter) lastRevision() string {
+	if obj.Status.Artifact == nil {
+		return ""
+	}
+	return obj.Status.Artifact.Revision
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/fluxcd/pkg/apis/meta"
+)
+
+func TestValidateReconcileFlags(t *testing.T) {
+	defaultArgs := reconcileArgs
+	defer func() {
+		reconcileArgs = defaultArgs
+	}()
+
+	tests := []struct {
+		name          string
+		output        string
+		annotationKey string
+		expectErr     bool
+	}{
+		{"default", "", meta.ReconcileRequestAnnotation, false},
+		{"json output", "json", meta.ReconcileRequestAnnotation, false},
+		{"unsupported output", "yaml", meta.ReconcileRequestAnnotation, true},
+		{"custom annotation key", "", "example.com/reconcile", false},
+		{"empty annotation key", "", "", true},
+		{"invalid annotation key", "", "example.com/re:concile", true},
+		{"invalid annotation prefix", "", "Example_com/reconcile", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconcileArgs = reconcileFlags{
+				output:        tt.output,
+				annotationKey: tt.annotationKey,
+			}
+			if err := validateReconcileFlags(); (err != nil) != tt.expectErr {
+				t.Errorf("validateReconcileFlags() error = %v, expectErr %v", err, tt.expectErr)
+			}
+		})
+	}
+}
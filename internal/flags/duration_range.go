@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DurationRange is a range of durations in the format '<min>:<max>',
+// where either bound can be omitted.
+type DurationRange struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (r *DurationRange) String() string {
+	if r.Min == 0 && r.Max == 0 {
+		return ""
+	}
+	var min, max string
+	if r.Min > 0 {
+		min = r.Min.String()
+	}
+	if r.Max > 0 {
+		max = r.Max.String()
+	}
+	return min + ":" + max
+}
+
+func (r *DurationRange) Set(str string) error {
+	parts := strings.Split(str, ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid duration range '%s', must be in the format '<min>:<max>'", str)
+	}
+	if strings.TrimSpace(parts[0]) == "" && strings.TrimSpace(parts[1]) == "" {
+		return fmt.Errorf("no duration range given, at least one of min or max is required")
+	}
+
+	var bounds [2]time.Duration
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return fmt.Errorf("invalid duration '%s' in range: %w", part, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid duration '%s' in range, must be positive", part)
+		}
+		bounds[i] = d
+	}
+	if bounds[0] > 0 && bounds[1] > 0 && bounds[0] > bounds[1] {
+		return fmt.Errorf("invalid duration range '%s', min is greater than max", str)
+	}
+
+	r.Min, r.Max = bounds[0], bounds[1]
+	return nil
+}
+
+func (r *DurationRange) Type() string {
+	return "durationRange"
+}
+
+func (r *DurationRange) Description() string {
+	return "duration range in the format '<min>:<max>', either bound can be omitted"
+}
+
+// Contains reports whether the duration is within the range.
+func (r *DurationRange) Contains(d time.Duration) bool {
+	return (r.Min == 0 || d >= r.Min) && (r.Max == 0 || d <= r.Max)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flags
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationRange_Set(t *testing.T) {
+	tests := []struct {
+		name      string
+		str       string
+		expect    string
+		expectErr bool
+	}{
+		{"min and max", "1m:1h", "1m0s:1h0m0s", false},
+		{"min only", "30s:", "30s:", false},
+		{"max only", ":10m", ":10m0s", false},
+		{"min greater than max", "1h:1m", "", true},
+		{"invalid duration", "1x:1h", "", true},
+		{"negative duration", "-1m:", "", true},
+		{"no separator", "1m", "", true},
+		{"empty bounds", ":", "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r DurationRange
+			if err := r.Set(tt.str); (err != nil) != tt.expectErr {
+				t.Errorf("Set() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if str := r.String(); str != tt.expect {
+				t.Errorf("Set() = %v, expect %v", str, tt.expect)
+			}
+		})
+	}
+}
+
+func TestDurationRange_Contains(t *testing.T) {
+	tests := []struct {
+		name   string
+		r      DurationRange
+		d      time.Duration
+		expect bool
+	}{
+		{"within", DurationRange{Min: time.Minute, Max: time.Hour}, 10 * time.Minute, true},
+		{"below", DurationRange{Min: time.Minute, Max: time.Hour}, time.Second, false},
+		{"above", DurationRange{Min: time.Minute, Max: time.Hour}, 2 * time.Hour, false},
+		{"no max", DurationRange{Min: time.Minute}, 24 * time.Hour, true},
+		{"no min", DurationRange{Max: time.Hour}, time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Contains(tt.d); got != tt.expect {
+				t.Errorf("Contains() = %v, expect %v", got, tt.expect)
+			}
+		})
+	}
+}
@@ -32,8 +32,10 @@ import (
 	"text/template"
 
 	"github.com/olekukonko/tablewriter"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -162,7 +164,38 @@ func KubeClient(kubeConfigPath string, kubeContext string) (client.Client, error
 		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
 	}
 
+	kubeClient, err := client.New(cfg, client.Options{
+		Scheme: kubeScheme(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
+	}
+
+	return kubeClient, nil
+}
+
+// KubeWatchClient returns a client that, in addition to the regular
+// client operations, is able to watch objects for changes.
+func KubeWatchClient(kubeConfigPath string, kubeContext string) (client.WithWatch, error) {
+	cfg, err := KubeConfig(kubeConfigPath, kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
+	}
+
+	kubeClient, err := client.NewWithWatch(cfg, client.Options{
+		Scheme: kubeScheme(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
+	}
+
+	return kubeClient, nil
+}
+
+func kubeScheme() *apiruntime.Scheme {
 	scheme := apiruntime.NewScheme()
+	_ = apiextensionsv1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
 	_ = corev1.AddToScheme(scheme)
 	_ = rbacv1.AddToScheme(scheme)
 	_ = sourcev1.AddToScheme(scheme)
@@ -171,15 +204,7 @@ func KubeClient(kubeConfigPath string, kubeContext string) (client.Client, error
 	_ = notificationv1.AddToScheme(scheme)
 	_ = imagereflectv1.AddToScheme(scheme)
 	_ = imageautov1.AddToScheme(scheme)
-
-	kubeClient, err := client.New(cfg, client.Options{
-		Scheme: scheme,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("kubernetes client initialization failed: %w", err)
-	}
-
-	return kubeClient, nil
+	return scheme
 }
 
 // SplitKubeConfigPath splits the given KUBECONFIG path based on the runtime OS
@@ -382,6 +407,44 @@ func PrintTable(writer io.Writer, header []string, rows [][]string) {
 	table.Render()
 }
 
+// PrintStyledTable prints the table in the given style: plain is the
+// same as PrintTable, bordered draws borders around the cells and
+// markdown renders a GitHub-flavored pipe table.
+func PrintStyledTable(writer io.Writer, header []string, rows [][]string, style string) {
+	switch style {
+	case "bordered":
+		table := tablewriter.NewWriter(writer)
+		table.SetHeader(header)
+		table.SetAutoWrapText(false)
+		table.SetAutoFormatHeaders(true)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.AppendBulk(rows)
+		table.Render()
+	case "markdown":
+		var escaped [][]string
+		for _, row := range rows {
+			var cells []string
+			for _, cell := range row {
+				cells = append(cells, strings.ReplaceAll(cell, "|", "\\|"))
+			}
+			escaped = append(escaped, cells)
+		}
+		table := tablewriter.NewWriter(writer)
+		table.SetHeader(header)
+		table.SetAutoWrapText(false)
+		table.SetAutoFormatHeaders(false)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.AppendBulk(escaped)
+		table.Render()
+	default:
+		PrintTable(writer, header, rows)
+	}
+}
+
 func ValidateComponents(components []string) error {
 	defaults := install.MakeDefaultOptions()
 	bootstrapAllComponents := append(defaults.Components, defaults.ComponentsExtra...)